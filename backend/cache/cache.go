@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Kk120306/cvwo-2026/backend/config"
+)
+
+// Cache is a byte cache shared by the services for hot read endpoints
+// Implementations must be safe for concurrent use
+type Cache interface {
+	// Get returns the cached value and whether it was found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores a value that expires after ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the given keys
+	Delete(ctx context.Context, keys ...string) error
+	// DeletePrefix removes every key that starts with prefix
+	DeletePrefix(ctx context.Context, prefix string) error
+	// Close releases any connections held by the cache
+	Close() error
+}
+
+// Store is the cache used by the services - works like database.DB
+// Defaults to a no-op cache so services work before Init is called (e.g. seeding, commands)
+var Store Cache = noopCache{}
+
+// how long entries live unless invalidated first
+var ttl = 30 * time.Second
+
+// Init creates the cache picked by the config
+func Init(cfg config.CacheConfig) error {
+	ttl = cfg.TTL
+
+	switch cfg.Driver {
+	case "memory":
+		Store = NewLRU(cfg.Size)
+	case "redis":
+		redisCache, err := NewRedis(cfg.RedisURL)
+		if err != nil {
+			return err
+		}
+		Store = redisCache
+	case "none":
+		Store = noopCache{}
+	default:
+		return fmt.Errorf("unknown cache driver %q", cfg.Driver)
+	}
+
+	return nil
+}
+
+// Close closes the active cache
+func Close() error {
+	return Store.Close()
+}
+
+// The helpers below are what services use - caching is best effort
+// so errors are only logged and callers fall back to the database
+
+// GetJSON decodes the cached value into dst, returns false on a miss or any error
+func GetJSON(key string, dst any) bool {
+	data, found, err := Store.Get(context.Background(), key)
+	if err != nil {
+		log.Printf("cache get %s failed: %v", key, err)
+		return false
+	}
+	if !found {
+		return false
+	}
+
+	if err := json.Unmarshal(data, dst); err != nil {
+		log.Printf("cache decode %s failed: %v", key, err)
+		return false
+	}
+
+	return true
+}
+
+// SetJSON encodes value and stores it under key
+func SetJSON(key string, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("cache encode %s failed: %v", key, err)
+		return
+	}
+
+	if err := Store.Set(context.Background(), key, data, ttl); err != nil {
+		log.Printf("cache set %s failed: %v", key, err)
+	}
+}
+
+// Invalidate removes the given keys
+func Invalidate(keys ...string) {
+	if err := Store.Delete(context.Background(), keys...); err != nil {
+		log.Printf("cache invalidate %v failed: %v", keys, err)
+	}
+}
+
+// InvalidatePrefix removes every key under prefix
+func InvalidatePrefix(prefix string) {
+	if err := Store.DeletePrefix(context.Background(), prefix); err != nil {
+		log.Printf("cache invalidate %s* failed: %v", prefix, err)
+	}
+}
+
+// noopCache never stores anything, used when caching is disabled
+type noopCache struct{}
+
+func (noopCache) Get(context.Context, string) ([]byte, bool, error)        { return nil, false, nil }
+func (noopCache) Set(context.Context, string, []byte, time.Duration) error { return nil }
+func (noopCache) Delete(context.Context, ...string) error                  { return nil }
+func (noopCache) DeletePrefix(context.Context, string) error               { return nil }
+func (noopCache) Close() error                                             { return nil }
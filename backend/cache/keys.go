@@ -0,0 +1,33 @@
+package cache
+
+import "fmt"
+
+// Key prefixes - invalidation works on these so keep related keys under the same one
+
+// FeedPrefix covers every cached anonymous feed page
+const FeedPrefix = "feed:"
+
+// TopicsKey is the cached topic list
+// Kept under FeedPrefix as it carries post counts and the latest post of each topic
+const TopicsKey = FeedPrefix + "topics"
+
+// AllPostsKey is the anonymous /posts/all feed
+func AllPostsKey() string {
+	return FeedPrefix + "all"
+}
+
+// TopicPostsKey is the anonymous feed of one topic
+func TopicPostsKey(slug string) string {
+	return FeedPrefix + "topic:" + slug
+}
+
+// VoteCountKey is the like / dislike count of a post or comment
+func VoteCountKey(votableType, votableID string) string {
+	return fmt.Sprintf("votes:%s:%s", votableType, votableID)
+}
+
+// ReactionTypesKey is the list of reactions users can pick from
+const ReactionTypesKey = "reactions:types"
+
+// FilterRulesKey is the list of enabled content filter rules, read on every post and comment
+const FilterRulesKey = "filter:rules"
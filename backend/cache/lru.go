@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LRU is an in memory cache that evicts the least recently used entry once full
+// Good enough for a single instance, use Redis when running more than one
+type LRU struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+// lruEntry is what each list element holds
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRU creates an LRU holding at most size entries
+func NewLRU(size int) *LRU {
+	if size <= 0 {
+		size = 1
+	}
+
+	return &LRU{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value if present and not expired
+func (l *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		l.remove(element)
+		return nil, false, nil
+	}
+
+	l.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+// Set stores the value, evicting the oldest entry when over capacity
+func (l *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+
+	// Existing key - update in place
+	if element, ok := l.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		l.order.MoveToFront(element)
+		return nil
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+
+	if l.order.Len() > l.size {
+		l.remove(l.order.Back())
+	}
+
+	return nil
+}
+
+// Delete removes the given keys
+func (l *LRU) Delete(_ context.Context, keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := l.entries[key]; ok {
+			l.remove(element)
+		}
+	}
+
+	return nil
+}
+
+// DeletePrefix removes every key starting with prefix
+func (l *LRU) DeletePrefix(_ context.Context, prefix string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, element := range l.entries {
+		if strings.HasPrefix(key, prefix) {
+			l.remove(element)
+		}
+	}
+
+	return nil
+}
+
+// Close is a no-op, nothing to release
+func (l *LRU) Close() error {
+	return nil
+}
+
+// remove drops an element from both the list and the map - caller holds the lock
+func (l *LRU) remove(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.entries, element.Value.(*lruEntry).key)
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a cache backed by anything speaking the Redis protocol (Redis, Valkey, KeyDB, ...)
+// Shared between instances so invalidation on one is seen by all
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis connects using a redis:// URL
+// https://redis.uptrace.dev/guide/go-redis.html#connecting-to-redis-server
+func NewRedis(url string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &Redis{client: client}, nil
+}
+
+// Get returns the value if present
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+// Set stores the value with an expiry
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Delete removes the given keys
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// DeletePrefix removes every key starting with prefix
+// SCAN instead of KEYS so a large keyspace does not block the server
+func (r *Redis) DeletePrefix(ctx context.Context, prefix string) error {
+	iter := r.client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	return r.Delete(ctx, keys...)
+}
+
+// Close closes the connection pool
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
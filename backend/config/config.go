@@ -1,136 +1,419 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/Kk120306/cvwo-2026/backend/helpers"
+	"github.com/goccy/go-yaml"
+	"github.com/joho/godotenv"
+	toml "github.com/pelletier/go-toml/v2"
 )
 
 // Config struct for holding all configurations
+// Config is the only place settings are read from - every other package gets it injected
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	CORS     CORSConfig
-	JWT      JWTConfig
-	AWS      AWSConfig
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	CORS      CORSConfig      `yaml:"cors"`
+	JWT       JWTConfig       `yaml:"jwt"`
+	AWS       AWSConfig       `yaml:"aws"`
+	Cache     CacheConfig     `yaml:"cache"`
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+
+	LinkPreview LinkPreviewConfig `yaml:"link_preview"`
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Port string
-	Env  string // development or production
+	Port string `yaml:"port"`
+	Env  string `yaml:"env"` // development or production
+
+	// How long readiness reports failing before the server starts draining
+	// gives the load balancer / compose healthcheck time to stop routing to us
+	DrainDelay time.Duration `yaml:"drain_delay"`
+	// How long in flight requests get to finish once draining starts
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	URL            string
-	Host           string
-	Port           string
-	User           string
-	Password       string
-	Name           string
-	SSLMode        string
-	ChannelBinding string
+	URL      string `yaml:"url"`
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+	// Not part of the built DSN - pgx does not support it and would send it to the server as a runtime parameter
+	ChannelBinding string `yaml:"channel_binding"`
+
+	// Connection pool limits - https://pkg.go.dev/database/sql#DB.SetMaxOpenConns
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
 }
 
 // CORSConfig holds CORS configuration
 type CORSConfig struct {
-	AllowedOrigins   []string
-	AllowedMethods   []string
-	AllowedHeaders   []string
-	ExposeHeaders    []string
-	AllowCredentials bool
-	MaxAge           time.Duration
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposeHeaders    []string      `yaml:"expose_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
 }
 
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
-	Secret string
+	Secret string `yaml:"secret"`
 }
 
 // AWSConfig holds AWS configuration
 type AWSConfig struct {
-	AccessKeyID            string
-	SecretAccessKey        string
-	CloudFrontDistribution string
+	AccessKeyID            string `yaml:"access_key_id"`
+	SecretAccessKey        string `yaml:"secret_access_key"`
+	CloudFrontDistribution string `yaml:"cloudfront_distribution"`
+	Region                 string `yaml:"region"`
+	Bucket                 string `yaml:"bucket"`
+	// Where uploaded images are served from, avatars have to live under it
+	ImageBaseURL string `yaml:"image_base_url"`
 }
 
-// loads and returns application configuration
-func Load() *Config {
-	// Load environment variables
-	helpers.LoadEnvVariables()
+// CacheConfig holds response cache configuration
+type CacheConfig struct {
+	Driver   string        `yaml:"driver"`    // memory, redis or none
+	RedisURL string        `yaml:"redis_url"` // only used by the redis driver
+	Size     int           `yaml:"size"`      // max entries for the memory driver
+	TTL      time.Duration `yaml:"ttl"`       // safety net, writes invalidate entries straight away
+}
 
-	// Build database configuration
-	dbConfig := DatabaseConfig{
-		URL:            getEnv("DATABASE_URL", ""),
-		Host:           getEnv("DB_HOST", ""),
-		Port:           getEnv("DB_PORT", "5432"),
-		User:           getEnv("DB_USER", ""),
-		Password:       getEnv("DB_PASSWORD", ""),
-		Name:           getEnv("DB_NAME", ""),
-		SSLMode:        getEnv("DB_SSLMODE", "require"),
-		ChannelBinding: getEnv("DB_CHANNEL_BINDING", "require"),
-	}
+// SchedulerConfig holds background job configuration
+type SchedulerConfig struct {
+	PublishInterval time.Duration `yaml:"publish_interval"` // how often scheduled posts are checked
+}
 
-	// for individual parts, have to build it into a URL
-	if dbConfig.URL == "" && dbConfig.Host != "" {
-		dbConfig.URL = fmt.Sprintf(
-			"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s channel_binding=%s",
-			dbConfig.Host,
-			dbConfig.User,
-			dbConfig.Password,
-			dbConfig.Name,
-			dbConfig.Port,
-			dbConfig.SSLMode,
-			dbConfig.ChannelBinding,
-		)
-	}
+// LinkPreviewConfig holds the limits on fetching link previews from other sites
+type LinkPreviewConfig struct {
+	Timeout  time.Duration `yaml:"timeout"`   // for the whole fetch, redirects included
+	MaxBytes int           `yaml:"max_bytes"` // how much of a page is read looking for metadata
+	TTL      time.Duration `yaml:"ttl"`       // how long a fetched preview is used before fetching it again
+}
 
-	// return pointer cus we dont want a copy
+// Default returns the configuration used when neither a config file nor env vars set a value
+func Default() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Env:  getEnv("ENV", "development"),
+			Port:            "8080",
+			Env:             "development",
+			DrainDelay:      5 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Port:            "5432",
+			SSLMode:         "require",
+			ChannelBinding:  "require",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
 		},
-		Database: dbConfig,
 		CORS: CORSConfig{
-			AllowedOrigins:   []string{getEnv("FRONTEND_URL", "http://localhost:3000")},
+			AllowedOrigins:   []string{"http://localhost:3000"},
 			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-			AllowedHeaders:   []string{"Origin", "Content-Type", "Accept", "Authorization"},
+			AllowedHeaders:   []string{"Origin", "Content-Type", "Accept", "Authorization", "Idempotency-Key"},
 			ExposeHeaders:    []string{"Content-Length"},
 			AllowCredentials: true,
 			MaxAge:           12 * time.Hour,
 		},
-		JWT: JWTConfig{
-			Secret: getEnv("SECRET", ""),
-		},
 		AWS: AWSConfig{
-			AccessKeyID:            getEnv("AWS_ACCESS_KEY_ID", ""),
-			SecretAccessKey:        getEnv("AWS_SECRET_ACCESS_KEY", ""),
-			CloudFrontDistribution: getEnv("CLOUDFRONT_DISTRIBUTION_ID", ""),
+			// inside url values so no need to hide these
+			Region:       "ap-southeast-2",
+			Bucket:       "direct-upload-s3-cvwo",
+			ImageBaseURL: "https://d1nxlczpemry9k.cloudfront.net",
 		},
+		Cache: CacheConfig{
+			Driver: "memory",
+			Size:   1000,
+			TTL:    30 * time.Second,
+		},
+		Scheduler: SchedulerConfig{
+			PublishInterval: 30 * time.Second,
+		},
+		LinkPreview: LinkPreviewConfig{
+			Timeout:  5 * time.Second,
+			MaxBytes: 512 * 1024,
+			TTL:      24 * time.Hour,
+		},
+	}
+}
+
+// Load builds the effective configuration
+// Precedence (lowest to highest): defaults -> config file (CONFIG_FILE) -> environment variables
+func Load() (*Config, error) {
+	// Load .env so its values act as environment variables
+	// https://github.com/joho/godotenv - just taken from here
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	cfg := Default()
+
+	// Optional YAML / TOML file
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	// Environment variables always win over the file
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
 	}
+
+	// for individual parts, have to build it into a URL
+	if cfg.Database.URL == "" && cfg.Database.Host != "" {
+		cfg.Database.URL = fmt.Sprintf(
+			"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+			cfg.Database.Host,
+			cfg.Database.User,
+			cfg.Database.Password,
+			cfg.Database.Name,
+			cfg.Database.Port,
+			cfg.Database.SSLMode,
+		)
+	}
+
+	return cfg, nil
+}
+
+// loadFile decodes a YAML or TOML file on top of cfg, picked by the file extension
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// https://github.com/goccy/go-yaml
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		// go-toml can not decode "5s" style strings into time.Duration
+		// so decode to a generic map first and let the YAML decoder (which can) fill the struct
+		var raw map[string]any
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		converted, err := yaml.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		if err := yaml.Unmarshal(converted, cfg); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file type %q, use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+
+	return nil
+}
+
+// applyEnv overrides any field whose environment variable is set
+// Values that fail to parse are returned together instead of being silently ignored
+func applyEnv(cfg *Config) error {
+	env := &envReader{}
+
+	env.setString(&cfg.Server.Port, "PORT")
+	env.setString(&cfg.Server.Env, "ENV")
+	env.setDuration(&cfg.Server.DrainDelay, "SHUTDOWN_DRAIN_DELAY")
+	env.setDuration(&cfg.Server.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+
+	env.setString(&cfg.Database.URL, "DATABASE_URL")
+	env.setString(&cfg.Database.Host, "DB_HOST")
+	env.setString(&cfg.Database.Port, "DB_PORT")
+	env.setString(&cfg.Database.User, "DB_USER")
+	env.setString(&cfg.Database.Password, "DB_PASSWORD")
+	env.setString(&cfg.Database.Name, "DB_NAME")
+	env.setString(&cfg.Database.SSLMode, "DB_SSLMODE")
+	env.setString(&cfg.Database.ChannelBinding, "DB_CHANNEL_BINDING")
+	env.setInt(&cfg.Database.MaxOpenConns, "DB_MAX_OPEN_CONNS")
+	env.setInt(&cfg.Database.MaxIdleConns, "DB_MAX_IDLE_CONNS")
+	env.setDuration(&cfg.Database.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME")
+	env.setDuration(&cfg.Database.ConnMaxIdleTime, "DB_CONN_MAX_IDLE_TIME")
+
+	env.setList(&cfg.CORS.AllowedOrigins, "FRONTEND_URL")
+
+	env.setString(&cfg.JWT.Secret, "SECRET")
+
+	env.setString(&cfg.AWS.AccessKeyID, "AWS_ACCESS_KEY_ID")
+	env.setString(&cfg.AWS.SecretAccessKey, "AWS_SECRET_ACCESS_KEY")
+	env.setString(&cfg.AWS.CloudFrontDistribution, "CLOUDFRONT_DISTRIBUTION_ID")
+	env.setString(&cfg.AWS.Region, "AWS_REGION")
+	env.setString(&cfg.AWS.Bucket, "S3_BUCKET")
+	env.setString(&cfg.AWS.ImageBaseURL, "IMAGE_BASE_URL")
+
+	env.setString(&cfg.Cache.Driver, "CACHE_DRIVER")
+	env.setString(&cfg.Cache.RedisURL, "REDIS_URL")
+	env.setInt(&cfg.Cache.Size, "CACHE_SIZE")
+	env.setDuration(&cfg.Cache.TTL, "CACHE_TTL")
+
+	env.setDuration(&cfg.Scheduler.PublishInterval, "PUBLISH_INTERVAL")
+
+	env.setDuration(&cfg.LinkPreview.Timeout, "LINK_PREVIEW_TIMEOUT")
+	env.setInt(&cfg.LinkPreview.MaxBytes, "LINK_PREVIEW_MAX_BYTES")
+	env.setDuration(&cfg.LinkPreview.TTL, "LINK_PREVIEW_TTL")
+
+	return errors.Join(env.errs...)
 }
 
 // Validate checks if all required configuration is present
+// Every problem is collected so a bad deploy shows all of them at once
 func (c *Config) Validate() error {
+	var errs []error
+
 	if c.Database.URL == "" {
-		log.Fatal("Database configuration is required in the env file")
+		errs = append(errs, errors.New("database configuration is required (DATABASE_URL or DB_HOST)"))
 	}
 	if c.JWT.Secret == "" {
-		log.Fatal("SECRET is required for JWT authentication in the env file")
+		errs = append(errs, errors.New("SECRET is required for JWT authentication"))
+	}
+	if c.Server.Env != "development" && c.Server.Env != "production" {
+		errs = append(errs, fmt.Errorf("ENV must be development or production, got %q", c.Server.Env))
+	}
+	if _, err := strconv.Atoi(c.Server.Port); err != nil {
+		errs = append(errs, fmt.Errorf("PORT must be a number, got %q", c.Server.Port))
+	}
+	if c.Server.DrainDelay < 0 || c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("SHUTDOWN_DRAIN_DELAY must not be negative and SHUTDOWN_TIMEOUT must be positive"))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS must not be negative"))
+	}
+	// 0 means unlimited open connections, otherwise idle can not exceed open
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, errors.New("DB_MAX_IDLE_CONNS can not be larger than DB_MAX_OPEN_CONNS"))
+	}
+	if len(c.CORS.AllowedOrigins) == 0 {
+		errs = append(errs, errors.New("at least one allowed CORS origin (FRONTEND_URL) is required"))
+	}
+	if c.AWS.Region == "" || c.AWS.Bucket == "" {
+		errs = append(errs, errors.New("AWS region and S3 bucket are required"))
+	}
+	if u, err := url.Parse(c.AWS.ImageBaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("IMAGE_BASE_URL must be an http(s) URL, got %q", c.AWS.ImageBaseURL))
+	}
+
+	switch c.Cache.Driver {
+	case "memory", "none":
+	case "redis":
+		if c.Cache.RedisURL == "" {
+			errs = append(errs, errors.New("REDIS_URL is required when CACHE_DRIVER is redis"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("CACHE_DRIVER must be memory, redis or none, got %q", c.Cache.Driver))
+	}
+	if c.Cache.Size <= 0 || c.Cache.TTL <= 0 {
+		errs = append(errs, errors.New("CACHE_SIZE and CACHE_TTL must be positive"))
+	}
+	if c.Scheduler.PublishInterval <= 0 {
+		errs = append(errs, errors.New("PUBLISH_INTERVAL must be positive"))
+	}
+	if c.LinkPreview.Timeout <= 0 || c.LinkPreview.MaxBytes <= 0 || c.LinkPreview.TTL <= 0 {
+		errs = append(errs, errors.New("LINK_PREVIEW_TIMEOUT, LINK_PREVIEW_MAX_BYTES and LINK_PREVIEW_TTL must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy with secrets masked so it is safe to print or log
+func (c *Config) Redacted() *Config {
+	copied := *c
+
+	copied.Database.Password = redact(c.Database.Password)
+	copied.JWT.Secret = redact(c.JWT.Secret)
+	copied.AWS.AccessKeyID = redact(c.AWS.AccessKeyID)
+	copied.AWS.SecretAccessKey = redact(c.AWS.SecretAccessKey)
+
+	// The URL embeds the password so it is masked as a whole, host and friends stay readable
+	copied.Database.URL = redact(c.Database.URL)
+	// redis:// URLs can carry a password too
+	copied.Cache.RedisURL = redact(c.Cache.RedisURL)
+
+	return &copied
+}
+
+// redact masks a secret while still showing whether it was set
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+// envReader applies env overrides and remembers values that could not be parsed
+type envReader struct {
+	errs []error
+}
+
+// setString overrides target when the env var is set
+func (e *envReader) setString(target *string, key string) {
+	if value := os.Getenv(key); value != "" {
+		*target = value
 	}
-	return nil
 }
 
-// getEnv gets an environment variable with a default fallback
-func getEnv(key, defaultValue string) string {
+// setInt overrides target when the env var is set to an integer
+func (e *envReader) setInt(target *int, key string) {
 	value := os.Getenv(key)
 	if value == "" {
-		return defaultValue
+		return
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be an integer, got %q", key, value))
+		return
+	}
+	*target = parsed
+}
+
+// setDuration overrides target when the env var is set to a duration (e.g. "30s", "5m")
+func (e *envReader) setDuration(target *time.Duration, key string) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be a duration like 30s or 5m, got %q", key, value))
+		return
+	}
+	*target = parsed
+}
+
+// setList overrides target with a comma separated env var
+func (e *envReader) setList(target *[]string, key string) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
-	return value
+	*target = items
 }
@@ -0,0 +1,105 @@
+package content
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// longer links are not previewed, they would not fit the unique index on link_previews anyway
+const maxLinkLength = 2048
+
+// bare links in text, the editor and markdown normally turn these into anchors already
+var bareLink = regexp.MustCompile(`https?://[^\s<>"']+`)
+
+// FirstLink returns the first http(s) link in sanitized HTML, anchors first then bare links in the text
+// Links within the site are relative and never count
+func FirstLink(sanitized string) string {
+	var text strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(sanitized))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			// punctuation straight after a link is almost always the end of the sentence
+			return previewable(strings.TrimRight(bareLink.FindString(text.String()), ".,;:!?)"))
+		case html.TextToken:
+			text.Write(tokenizer.Text())
+			text.WriteByte(' ')
+		case html.StartTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if string(name) != "a" {
+				continue
+			}
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = tokenizer.TagAttr()
+				if string(key) == "href" {
+					if link := previewable(string(value)); link != "" {
+						return link
+					}
+				}
+			}
+		}
+	}
+}
+
+// previewable returns the link if it is an absolute http(s) url that can be previewed, otherwise ""
+func previewable(link string) string {
+	if link == "" || len(link) > maxLinkLength {
+		return ""
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return ""
+	}
+	// the fragment is only for the browser, dropping it lets more posts share a preview
+	u.Fragment = ""
+	return u.String()
+}
+
+// Links returns every http(s) link in sanitized HTML, anchors and bare links in the text outside of them
+// Repeats are kept, the same link posted ten times is still ten links
+func Links(sanitized string) []string {
+	var links []string
+	var text strings.Builder
+	inAnchor := 0
+	tokenizer := html.NewTokenizer(strings.NewReader(sanitized))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			for _, link := range bareLink.FindAllString(text.String(), -1) {
+				if link = previewable(strings.TrimRight(link, ".,;:!?)")); link != "" {
+					links = append(links, link)
+				}
+			}
+			return links
+		case html.TextToken:
+			// linkified text repeats its href, it is not another link
+			if inAnchor == 0 {
+				text.Write(tokenizer.Text())
+				text.WriteByte(' ')
+			}
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "a" && inAnchor > 0 {
+				inAnchor--
+			}
+		case html.StartTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if string(name) != "a" {
+				continue
+			}
+			inAnchor++
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = tokenizer.TagAttr()
+				if string(key) == "href" {
+					if link := previewable(string(value)); link != "" {
+						links = append(links, link)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package content
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Kk120306/cvwo-2026/backend/helpers"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// Limits are the lengths allowed for one kind of content, counted in characters
+// A MaxTitle of 0 means the content has no title
+type Limits struct {
+	MaxTitle int
+	MaxBody  int
+}
+
+// Limits of the content types - bodies are measured as submitted, html or markdown
+var (
+	PostLimits    = Limits{MaxTitle: 200, MaxBody: 40000}
+	CommentLimits = Limits{MaxBody: 10000}
+	MessageLimits = Limits{MaxBody: 5000}
+)
+
+// Body is submitted content ready to store
+type Body struct {
+	Format string
+	HTML   string  // sanitized, what gets shown
+	Source *string // the markdown as written, nil for html
+}
+
+var languageClass = regexp.MustCompile(`^language-[\w+#-]+$`)
+
+// policy decides what user written HTML may contain, built once as bluemonday policies are safe to share
+// Covers what the rich text editor and the markdown renderer produce, anything else is dropped
+// https://github.com/microcosm-cc/bluemonday - prevent XSS attacks
+var policy = func() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+
+	p.AllowElements("p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote", "pre",
+		"strong", "b", "em", "i", "u", "s", "del", "strike", "sub", "sup", "ul", "ol", "li",
+		"table", "thead", "tbody", "tr")
+	p.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+	p.AllowElements("th", "td")
+
+	// class only for the language of code blocks, which highlighters look for
+	p.AllowAttrs("class").Matching(languageClass).OnElements("code")
+	p.AllowElements("code")
+
+	// markdown task lists
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+
+	p.AllowStandardURLs()
+	p.AllowAttrs("href", "title").OnElements("a")
+	p.AllowAttrs("src", "alt", "title").OnElements("img")
+	p.AllowAttrs("width", "height").Matching(bluemonday.NumberOrPercent).OnElements("img")
+
+	// Every link gets rel="nofollow", links off the site also open in a new tab with noopener
+	// Relative links are the exception on purpose, they only get nofollow: they stay in the same tab
+	// and noopener only matters for a new tab, so there is no window.opener to protect
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return p
+}()
+
+// textPolicy strips every tag, leaving the text
+var textPolicy = bluemonday.StrictPolicy()
+
+// Sanitize cleans user written HTML with the shared policy
+func Sanitize(input string) string {
+	return policy.Sanitize(input)
+}
+
+// Title checks a title against the limits and returns it trimmed
+// Titles are plain text, escaping them is up to whoever shows them
+func Title(title string, limits Limits) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", errors.New("title cannot be empty")
+	}
+	if strings.ContainsAny(title, "\r\n") {
+		return "", errors.New("title must be a single line")
+	}
+	if utf8.RuneCountInString(title) > limits.MaxTitle {
+		return "", errors.New("title is too long")
+	}
+	return title, nil
+}
+
+// Clean turns content in the given format into sanitized HTML
+// html is what the rich text editor sends and is the default, markdown is rendered first
+// Content that has nothing left to show after sanitizing is rejected
+func Clean(format, input string, limits Limits) (*Body, error) {
+	if utf8.RuneCountInString(input) > limits.MaxBody {
+		return nil, errors.New("content is too long")
+	}
+
+	body := Body{Format: models.ContentFormatHTML}
+	unsafe := input
+
+	switch format {
+	case "", models.ContentFormatHTML:
+	case models.ContentFormatMarkdown:
+		rendered, err := helpers.RenderMarkdown(input)
+		if err != nil {
+			return nil, errors.New("failed to render markdown")
+		}
+		unsafe = rendered
+		body.Format = models.ContentFormatMarkdown
+		body.Source = &input
+	default:
+		return nil, errors.New("invalid format")
+	}
+
+	body.HTML = strings.TrimSpace(policy.Sanitize(unsafe))
+	if isBlank(body.HTML) {
+		return nil, errors.New("content cannot be empty")
+	}
+
+	return &body, nil
+}
+
+// isBlank reports whether sanitized HTML shows nothing, e.g. "<p> </p>" or a lone <br>
+// An image on its own counts as something
+func isBlank(sanitized string) bool {
+	if strings.Contains(sanitized, "<img") {
+		return false
+	}
+	text := html.UnescapeString(textPolicy.Sanitize(sanitized))
+	return strings.TrimSpace(strings.ReplaceAll(text, "\u00a0", " ")) == ""
+}
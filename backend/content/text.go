@@ -0,0 +1,48 @@
+package content
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// inline elements do not split words, "f<b>o</b>o" still reads as one word
+var inlineElements = map[string]bool{
+	"a": true, "b": true, "strong": true, "em": true, "i": true, "u": true, "s": true,
+	"del": true, "strike": true, "sub": true, "sup": true, "code": true,
+}
+
+// Text returns the text of sanitized HTML as a reader would see it, blocks on their own lines
+func Text(sanitized string) string {
+	var text strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(sanitized))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return text.String()
+		case html.TextToken:
+			text.Write(tokenizer.Text())
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			if name, _ := tokenizer.TagName(); !inlineElements[string(name)] {
+				text.WriteByte('\n')
+			}
+		}
+	}
+}
+
+// MapText rewrites every text node of sanitized HTML with fn, tags and attributes are left as they were
+// fn gets and returns unescaped text, the result is escaped again so it stays safe
+func MapText(sanitized string, fn func(string) string) string {
+	var out strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(sanitized))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return out.String()
+		case html.TextToken:
+			out.WriteString(html.EscapeString(fn(string(tokenizer.Text()))))
+		default:
+			out.Write(tokenizer.Raw())
+		}
+	}
+}
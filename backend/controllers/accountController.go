@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AccountController handles HTTP requests for muting, suspending and banning users
+// Every route is assumed to be behind CheckAuth and CheckAdmin
+type AccountController struct {
+	accountService *services.AccountService
+}
+
+// NewAccountController creates a new instance of AccountController
+func NewAccountController() *AccountController {
+	return &AccountController{
+		accountService: services.NewAccountService(),
+	}
+}
+
+// GetAccountStatus returns the current state of a users account
+func (ac *AccountController) GetAccountStatus(c *gin.Context) {
+	status, err := ac.accountService.GetAccountStatus(c.Param("username"))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "user not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"account": status})
+}
+
+// SetAccountStatus mutes, suspends, bans or restores a user, a reason is required
+// until is an RFC 3339 timestamp
+func (ac *AccountController) SetAccountStatus(c *gin.Context) {
+	var body struct {
+		Status string     `json:"status" binding:"required"`
+		Until  *time.Time `json:"until"`
+		Reason string     `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please provide a status and a reason"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	status, err := ac.accountService.SetAccountStatus(c.Param("username"), services.SetAccountStatusInput{
+		Status:  body.Status,
+		Until:   body.Until,
+		Reason:  body.Reason,
+		ActorID: user.ID,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "user not found":
+			statusCode = http.StatusNotFound
+		case "invalid account status", "suspensions need an until time",
+			"until is only allowed for mutes and suspensions", "until must be in the future":
+			statusCode = http.StatusBadRequest
+		case "you can not change your own account status", "admins can not be restricted":
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"account": status})
+}
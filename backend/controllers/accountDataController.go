@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AccountDataController handles HTTP requests for users exporting and deleting their data
+// Every route is assumed to be behind CheckAuth
+type AccountDataController struct {
+	accountDataService *services.AccountDataService
+}
+
+// NewAccountDataController creates a new instance of AccountDataController
+func NewAccountDataController(awsConfig config.AWSConfig) *AccountDataController {
+	return &AccountDataController{
+		accountDataService: services.NewAccountDataService(awsConfig),
+	}
+}
+
+// ExportData downloads everything stored about the logged in user
+// ?format=zip (default) includes copies of uploaded images, ?format=json only links to them
+func (adc *AccountDataController) ExportData(c *gin.Context) {
+	format := c.DefaultQuery("format", "zip")
+	if format != "zip" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be zip or json"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	export, err := adc.accountDataService.ExportUserData(user.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "user not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := "export-" + user.ID
+	if format == "json" {
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	// Streamed straight into the response, once it started a failure can only cut the download short
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+	c.Status(http.StatusOK)
+	if err := adc.accountDataService.WriteExportArchive(c.Writer, export); err != nil {
+		log.Printf("export archive error: %v", err)
+	}
+}
+
+// DeleteAccount deletes the logged in users account and signs them out
+// mode is anonymize (posts and comments stay under [deleted user]) or delete, username confirms it
+func (adc *AccountDataController) DeleteAccount(c *gin.Context) {
+	var body struct {
+		Mode     string `json:"mode" binding:"required"`
+		Username string `json:"username" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please provide a mode and your username to confirm"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	err := adc.accountDataService.DeleteAccount(user.ID, services.DeleteAccountInput{
+		Mode:     body.Mode,
+		Username: body.Username,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "user not found":
+			statusCode = http.StatusNotFound
+		case "invalid deletion mode", "username does not match":
+			statusCode = http.StatusBadRequest
+		case "admin accounts can not be deleted":
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Same as Logout - the token would not find the user anymore but the cookie can go now
+	c.SetCookie("Authorization", "", -1, "", "", false, true)
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted"})
+}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditController handles HTTP requests for the audit log - admin only
+type AuditController struct {
+	auditService *services.AuditService
+}
+
+// NewAuditController creates a new instance of AuditController
+func NewAuditController() *AuditController {
+	return &AuditController{
+		auditService: services.NewAuditService(),
+	}
+}
+
+// GetAuditLog returns a page of the audit log
+// Filters: ?actorId= &action= &targetType= &targetId= &from= &to= (RFC 3339), paging: ?page= &limit=
+func (ac *AuditController) GetAuditLog(c *gin.Context) {
+	filter, ok := auditFilterFromQuery(c)
+	if !ok {
+		return
+	}
+
+	page, pageErr := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, limitErr := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if pageErr != nil || limitErr != nil || page < 1 || limit < 1 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be at least 1 and limit between 1 and 200"})
+		return
+	}
+
+	result, err := ac.auditService.GetAuditLog(filter, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportAuditLog downloads the matching entries as ?format=csv or json (default)
+// Takes the same filters as GetAuditLog
+func (ac *AuditController) ExportAuditLog(c *gin.Context) {
+	filter, ok := auditFilterFromQuery(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or json"})
+		return
+	}
+
+	entries, err := ac.auditService.ExportAuditLog(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := "audit-log-" + time.Now().UTC().Format("20060102-150405") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	if format == "json" {
+		c.JSON(http.StatusOK, gin.H{"entries": entries})
+		return
+	}
+
+	// https://pkg.go.dev/encoding/csv
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"id", "createdAt", "actorId", "actorUsername", "action", "targetType", "targetId", "reason", "before", "after"})
+	for _, entry := range entries {
+		_ = w.Write([]string{
+			entry.ID,
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			entry.ActorID,
+			csvSafe(entry.ActorUsername),
+			entry.Action,
+			entry.TargetType,
+			entry.TargetID,
+			csvSafe(entry.Reason),
+			string(entry.Before),
+			string(entry.After),
+		})
+	}
+	w.Flush()
+}
+
+// auditFilterFromQuery reads the filters shared by listing and exporting
+func auditFilterFromQuery(c *gin.Context) (services.AuditFilter, bool) {
+	filter := services.AuditFilter{
+		ActorID:    c.Query("actorId"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("targetType"),
+		TargetID:   c.Query("targetId"),
+	}
+
+	for _, bound := range []struct {
+		key    string
+		target **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		value := c.Query(bound.key)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": bound.key + " must be an RFC 3339 time"})
+			return filter, false
+		}
+		*bound.target = &parsed
+	}
+
+	return filter, true
+}
+
+// csvSafe stops spreadsheet apps from running free text as a formula
+// https://owasp.org/www-community/attacks/CSV_Injection
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 
+	"github.com/Kk120306/cvwo-2026/backend/config"
 	"github.com/Kk120306/cvwo-2026/backend/models"
 	"github.com/Kk120306/cvwo-2026/backend/services"
 	"github.com/gin-gonic/gin"
@@ -14,9 +15,9 @@ type AuthController struct {
 }
 
 // NewAuthController creates a new instance of AuthController
-func NewAuthController() *AuthController {
+func NewAuthController(jwtConfig config.JWTConfig) *AuthController {
 	return &AuthController{
-		authService: services.NewAuthService(),
+		authService: services.NewAuthService(jwtConfig),
 	}
 }
 
@@ -82,6 +83,15 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
+	// Banned and suspended accounts do not get a new session
+	if err := ac.authService.CheckCanSignIn(user); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+			"until": user.StatusUntil,
+		})
+		return
+	}
+
 	// Generate token through service layer
 	tokenString, err := ac.authService.GenerateToken(user.ID)
 	if err != nil {
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// BlockController handles HTTP requests for blocking users
+// Every route is assumed to be behind CheckAuth
+type BlockController struct {
+	blockService *services.BlockService
+}
+
+// NewBlockController creates a new instance of BlockController
+func NewBlockController() *BlockController {
+	return &BlockController{
+		blockService: services.NewBlockService(),
+	}
+}
+
+// GetBlockedUsers lists the users the logged in user has blocked
+func (bc *BlockController) GetBlockedUsers(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	blocks, err := bc.blockService.GetBlockedUsers(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blocks": blocks})
+}
+
+// BlockUser blocks the user in the path
+func (bc *BlockController) BlockUser(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	err := bc.blockService.BlockUser(user.ID, c.Param("username"))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "user not found":
+			statusCode = http.StatusNotFound
+		case "you can not block yourself":
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blocked": true})
+}
+
+// UnblockUser unblocks the user in the path
+func (bc *BlockController) UnblockUser(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	err := bc.blockService.UnblockUser(user.ID, c.Param("username"))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "user not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blocked": false})
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BookmarkController handles HTTP requests for bookmarks
+// Every route is assumed to be behind CheckAuth
+type BookmarkController struct {
+	bookmarkService *services.BookmarkService
+}
+
+// NewBookmarkController creates a new instance of BookmarkController
+func NewBookmarkController() *BookmarkController {
+	return &BookmarkController{
+		bookmarkService: services.NewBookmarkService(),
+	}
+}
+
+// SaveBookmark bookmarks a post or comment, saving it again updates its folder and tags
+func (bc *BookmarkController) SaveBookmark(c *gin.Context) {
+	var body struct {
+		BookmarkableID   string   `json:"bookmarkableId" binding:"required"`   // ID of the post or comment
+		BookmarkableType string   `json:"bookmarkableType" binding:"required"` // "post" or "comment"
+		Folder           string   `json:"folder"`
+		Tags             []string `json:"tags"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if _, err := uuid.Parse(body.BookmarkableID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmarkable ID"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	bookmark, err := bc.bookmarkService.SaveBookmark(user.ID, services.BookmarkInput{
+		BookmarkableID:   body.BookmarkableID,
+		BookmarkableType: body.BookmarkableType,
+		Folder:           body.Folder,
+		Tags:             body.Tags,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "post not found", "comment not found":
+			statusCode = http.StatusNotFound
+		case "bookmarkableType must be 'post' or 'comment'", "folder name is too long", "tag is too long", "too many tags":
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bookmark": bookmark})
+}
+
+// RemoveBookmark removes the bookmark of a post or comment
+func (bc *BookmarkController) RemoveBookmark(c *gin.Context) {
+	bookmarkableType := c.Param("type") // must be "post" or "comment"
+	bookmarkableID := c.Param("id")
+
+	if bookmarkableType != "post" && bookmarkableType != "comment" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be 'post' or 'comment'"})
+		return
+	}
+	if _, err := uuid.Parse(bookmarkableID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmarkable ID"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	if err := bc.bookmarkService.RemoveBookmark(user.ID, bookmarkableType, bookmarkableID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed"})
+}
+
+// GetBookmarks lists the users bookmarks newest first
+// Filters: ?type= &folder= &tag=, paging: ?cursor= &limit=
+func (bc *BookmarkController) GetBookmarks(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	filter := services.BookmarkFilter{
+		Type: c.Query("type"),
+		Tag:  c.Query("tag"),
+	}
+	if filter.Type != "" && filter.Type != "post" && filter.Type != "comment" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be 'post' or 'comment'"})
+		return
+	}
+	// ?folder= with no value is the default folder, leaving it out lists every folder
+	if folder, ok := c.GetQuery("folder"); ok {
+		filter.Folder = &folder
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	page, err := bc.bookmarkService.GetBookmarks(user.ID, filter, c.Query("cursor"), limit)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "invalid cursor" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetBookmarkFolders lists the users bookmark folders with how many bookmarks each holds
+func (bc *BookmarkController) GetBookmarkFolders(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	folders, err := bc.bookmarkService.GetBookmarkFolders(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"folders": folders})
+}
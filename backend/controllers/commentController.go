@@ -32,26 +32,31 @@ func (cc *CommentController) GetCommentsByPost(c *gin.Context) {
 	}
 
 	// Check if user is authenticated
-	var userID *string
+	var viewer *models.User
 	u, exists := c.Get("user")
-	// If user is authenticated - we remember userID to join later
+	// If user is authenticated - we remember them to join their votes later
 	if exists {
 		user := u.(models.User)
-		userID = &user.ID
+		viewer = &user
 	}
 
 	// Get comments through service layer
-	comments, err := cc.commentService.GetCommentsByPost(postID, userID)
+	comments, newSince, err := cc.commentService.GetCommentsByPost(postID, viewer)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "post not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	// Return the comments
+	// Return the comments - newSince marks where the unread ones start for a logged in user
 	c.JSON(http.StatusOK, gin.H{
 		"comments": comments,
+		"newSince": newSince,
 	})
 }
 
@@ -85,6 +90,7 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 
 	var body struct {
 		Content string `json:"content" binding:"required"`
+		Format  string `json:"format"` // html (default) or markdown
 	}
 
 	err = c.ShouldBindJSON(&body)
@@ -98,10 +104,20 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 		PostID:   postID,
 		AuthorID: user.ID,
 		Content:  body.Content,
+		Format:   body.Format,
 	})
 
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		statusCode := http.StatusBadRequest
+		switch err.Error() {
+		case "post is locked", "topic is archived", "account is muted", "account is suspended", "account is banned",
+			"you can not reply to this user", "you can not mention a user who blocked you",
+			"content was blocked by the content filter":
+			statusCode = http.StatusForbidden
+		case "failed to create comment":
+			statusCode = http.StatusInternalServerError
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -113,6 +129,9 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 			"postId":    comment.PostID,
 			"authorId":  comment.AuthorID,
 			"content":   comment.Content,
+			"format":    comment.Format,
+			"source":    comment.Source,
+			"isHeld":    comment.IsHeld,
 			"createdAt": comment.CreatedAt,
 			"updatedAt": comment.UpdatedAt,
 			"author": gin.H{
@@ -169,6 +188,7 @@ func (cc *CommentController) UpdateComment(c *gin.Context) {
 	// Parse body
 	var body struct {
 		Content string `json:"content" binding:"required"`
+		Format  string `json:"format"` // html (default) or markdown
 	}
 
 	// check body
@@ -181,10 +201,20 @@ func (cc *CommentController) UpdateComment(c *gin.Context) {
 
 	// Update comment through service layer
 	err = cc.commentService.UpdateComment(comment, services.UpdateCommentInput{
-		Content: body.Content,
+		Content:  body.Content,
+		Format:   body.Format,
+		EditorID: user.ID,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "invalid format", "content cannot be empty", "content is too long":
+			statusCode = http.StatusBadRequest
+		case "account is muted", "account is suspended", "account is banned",
+			"you can not mention a user who blocked you", "content was blocked by the content filter":
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{
 			"error": err.Error(),
 		})
 		return
@@ -233,7 +263,8 @@ func (cc *CommentController) DeleteComment(c *gin.Context) {
 	}
 
 	// Delete comment through service layer
-	err = cc.commentService.DeleteComment(comment)
+	// Optional ?reason= kept in the audit log when an admin removes someone elses comment
+	err = cc.commentService.DeleteComment(comment, &user, c.Query("reason"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ContentFilterController handles HTTP requests for the content filter rules and held content - admin only
+type ContentFilterController struct {
+	contentFilterService *services.ContentFilterService
+}
+
+// NewContentFilterController creates a new instance of ContentFilterController
+func NewContentFilterController() *ContentFilterController {
+	return &ContentFilterController{
+		contentFilterService: services.NewContentFilterService(),
+	}
+}
+
+// filterErrorStatus maps content filter service errors to status codes
+func filterErrorStatus(err error) int {
+	switch err.Error() {
+	case "filter rule not found", "post not found", "comment not found":
+		return http.StatusNotFound
+	case "invalid rule kind", "invalid rule action", "only word and regex rules can mask", "pattern is required",
+		"pattern is too long", "invalid pattern", "pattern matches empty text", "invalid threshold":
+		return http.StatusBadRequest
+	case "post is not held", "comment is not held":
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// GetRules lists every filter rule with how often it matched
+func (fc *ContentFilterController) GetRules(c *gin.Context) {
+	rules, err := fc.contentFilterService.GetFilterRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// CreateRule adds a filter rule
+func (fc *ContentFilterController) CreateRule(c *gin.Context) {
+	var body struct {
+		Kind      string `json:"kind" binding:"required"`
+		Pattern   string `json:"pattern"`
+		Threshold int    `json:"threshold"`
+		Action    string `json:"action" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	rule, err := fc.contentFilterService.CreateFilterRule(services.CreateFilterRuleInput{
+		Kind:      body.Kind,
+		Pattern:   body.Pattern,
+		Threshold: body.Threshold,
+		Action:    body.Action,
+		ActorID:   c.MustGet("user").(models.User).ID,
+	})
+	if err != nil {
+		c.JSON(filterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"rule": rule})
+}
+
+// UpdateRule changes a filter rule or turns it on / off
+func (fc *ContentFilterController) UpdateRule(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	var body struct {
+		Pattern   *string `json:"pattern"`
+		Threshold *int    `json:"threshold"`
+		Action    *string `json:"action"`
+		IsEnabled *bool   `json:"isEnabled"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	rule, err := fc.contentFilterService.UpdateFilterRule(id, services.UpdateFilterRuleInput{
+		Pattern:   body.Pattern,
+		Threshold: body.Threshold,
+		Action:    body.Action,
+		IsEnabled: body.IsEnabled,
+		ActorID:   c.MustGet("user").(models.User).ID,
+	})
+	if err != nil {
+		c.JSON(filterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rule": rule})
+}
+
+// DeleteRule removes a filter rule and its hit statistics
+func (fc *ContentFilterController) DeleteRule(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	if err := fc.contentFilterService.DeleteFilterRule(id, user.ID); err != nil {
+		c.JSON(filterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Filter rule deleted successfully"})
+}
+
+// GetHeld lists the posts and comments waiting for approval
+func (fc *ContentFilterController) GetHeld(c *gin.Context) {
+	held, err := fc.contentFilterService.GetHeldContent()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, held)
+}
+
+// ApprovePost publishes a held post
+func (fc *ContentFilterController) ApprovePost(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	post, err := fc.contentFilterService.ApprovePost(id, user.ID)
+	if err != nil {
+		c.JSON(filterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"post": post})
+}
+
+// ApproveComment shows a held comment to everyone
+func (fc *ContentFilterController) ApproveComment(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	comment, err := fc.contentFilterService.ApproveComment(id, user.ID)
+	if err != nil {
+		c.JSON(filterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comment": comment})
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthController handles HTTP requests for health probes
+type HealthController struct {
+	healthService *services.HealthService
+}
+
+// NewHealthController creates a new instance of HealthController
+// Takes the app's HealthService so readiness sees the draining state set on shutdown
+func NewHealthController(healthService *services.HealthService) *HealthController {
+	return &HealthController{
+		healthService: healthService,
+	}
+}
+
+// Liveness - returns 200 as long as the process is serving requests
+func (hc *HealthController) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, hc.healthService.Liveness(c.Request.Context()))
+}
+
+// Readiness - returns 503 when the database is unreachable, migrations have not run or the server is draining
+func (hc *HealthController) Readiness(c *gin.Context) {
+	status, err := hc.healthService.Readiness(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, status)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
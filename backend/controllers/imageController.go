@@ -3,6 +3,8 @@ package controllers
 import (
 	"net/http"
 
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/Kk120306/cvwo-2026/backend/models"
 	"github.com/Kk120306/cvwo-2026/backend/services"
 	"github.com/gin-gonic/gin"
 )
@@ -13,16 +15,18 @@ type S3Controller struct {
 }
 
 // NewS3Controller creates a new instance of S3Controller
-func NewS3Controller() *S3Controller {
+func NewS3Controller(awsConfig config.AWSConfig) *S3Controller {
 	return &S3Controller{
-		s3Service: services.NewS3Service(),
+		s3Service: services.NewS3Service(awsConfig),
 	}
 }
 
 // Function to get s3 upload URL
 func (sc *S3Controller) GetS3UploadURL(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
 	// Generate upload URL through service layer
-	uploadURL, err := sc.s3Service.GenerateUploadURL()
+	uploadURL, err := sc.s3Service.GenerateUploadURL(user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -46,10 +50,16 @@ func (sc *S3Controller) DeleteS3Image(c *gin.Context) {
 		return
 	}
 
+	user := c.MustGet("user").(models.User)
+
 	// Delete image through service layer
-	err := sc.s3Service.DeleteImage(imageName)
+	err := sc.s3Service.DeleteImage(imageName, &user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "you can not delete this image" {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{
 			"error": err.Error(),
 		})
 		return
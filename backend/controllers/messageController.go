@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MessageController handles HTTP requests for direct messages
+// Every route is assumed to be behind CheckAuth
+type MessageController struct {
+	messageService *services.MessageService
+}
+
+// NewMessageController creates a new instance of MessageController
+func NewMessageController() *MessageController {
+	return &MessageController{
+		messageService: services.NewMessageService(),
+	}
+}
+
+const (
+	// keeps proxies from timing out an idle stream
+	streamHeartbeat = 25 * time.Second
+	// streams are reopened now and then so a ban or block takes effect without waiting for the client to leave
+	streamLifetime = 15 * time.Minute
+)
+
+// messageErrorStatus maps message service errors to status codes
+func messageErrorStatus(err error) int {
+	switch err.Error() {
+	case "conversation not found", "user not found":
+		return http.StatusNotFound
+	case "you can not message this user", "account is muted", "account is suspended", "account is banned":
+		return http.StatusForbidden
+	case "content cannot be empty", "content is too long", "conversation title is too long",
+		"conversations need at least one other user", "conversations can have at most 10 people",
+		"invalid cursor", "use either cursor or after":
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// GetConversations returns the users conversations, most recently active first, paged with ?cursor= &limit=
+func (mc *MessageController) GetConversations(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	page, err := mc.messageService.GetConversations(user.ID, c.Query("cursor"), limit)
+	if err != nil {
+		c.JSON(messageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// StartConversation sends a first message to one or more users
+func (mc *MessageController) StartConversation(c *gin.Context) {
+	var body struct {
+		Usernames []string `json:"usernames" binding:"required"`
+		Title     string   `json:"title"`
+		Content   string   `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	conversation, message, err := mc.messageService.StartConversation(services.StartConversationInput{
+		CreatorID: user.ID,
+		Usernames: body.Usernames,
+		Title:     body.Title,
+		Content:   body.Content,
+	})
+	if err != nil {
+		c.JSON(messageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"conversation": conversation, "message": message})
+}
+
+// GetMessages returns a page of a conversation, newest first
+// ?cursor= pages back to older messages, ?after= polls for messages newer than a latestCursor
+func (mc *MessageController) GetMessages(c *gin.Context) {
+	conversationID := c.Param("id")
+	if _, err := uuid.Parse(conversationID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	page, err := mc.messageService.GetMessages(conversationID, user.ID, c.Query("cursor"), c.Query("after"), limit)
+	if err != nil {
+		c.JSON(messageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// SendMessage adds a message to a conversation
+func (mc *MessageController) SendMessage(c *gin.Context) {
+	conversationID := c.Param("id")
+	if _, err := uuid.Parse(conversationID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+		return
+	}
+
+	var body struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	message, err := mc.messageService.SendMessage(conversationID, user.ID, body.Content)
+	if err != nil {
+		c.JSON(messageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": message})
+}
+
+// MarkRead marks a conversation as read, for clients that got its messages from the stream
+func (mc *MessageController) MarkRead(c *gin.Context) {
+	conversationID := c.Param("id")
+	if _, err := uuid.Parse(conversationID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	if err := mc.messageService.MarkConversationRead(conversationID, user.ID); err != nil {
+		c.JSON(messageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"read": true})
+}
+
+// GetUnreadCount returns how many unread messages the user has in total
+func (mc *MessageController) GetUnreadCount(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	count, err := mc.messageService.GetUnreadCount(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread": count})
+}
+
+// Stream sends new messages as server-sent events while the connection is open
+// Events only cover this instance, clients should still poll with ?after= when the stream drops
+// https://developer.mozilla.org/en-US/docs/Web/API/Server-sent_events/Using_server-sent_events
+func (mc *MessageController) Stream(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	events, unsubscribe := mc.messageService.Subscribe(user.ID)
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	lifetime := time.NewTimer(streamLifetime)
+	defer lifetime.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	// nginx buffers responses by default, which would hold events back
+	c.Header("X-Accel-Buffering", "no")
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false // server shutting down
+			}
+			c.SSEvent("message", event)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", "")
+			return true
+		case <-lifetime.C:
+			return false
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
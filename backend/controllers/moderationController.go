@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ModerationController handles HTTP requests for pinning, locking and archiving
+// Every route is assumed to be behind CheckAuth and CheckAdmin
+type ModerationController struct {
+	moderationService *services.ModerationService
+}
+
+// NewModerationController creates a new instance of ModerationController
+func NewModerationController() *ModerationController {
+	return &ModerationController{
+		moderationService: services.NewModerationService(),
+	}
+}
+
+// ModeratePost pins / unpins and locks / unlocks a post, a reason is required
+func (mc *ModerationController) ModeratePost(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var body struct {
+		IsPinned *bool  `json:"isPinned"`
+		IsLocked *bool  `json:"isLocked"`
+		Reason   string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please provide the changes and a reason"})
+		return
+	}
+
+	mc.moderatePost(c, id, services.ModeratePostInput{
+		IsPinned: body.IsPinned,
+		IsLocked: body.IsLocked,
+		Reason:   body.Reason,
+	})
+}
+
+// TogglePinPost pins or unpins a post - kept for the pin button, the reason is optional here
+func (mc *ModerationController) TogglePinPost(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	// Parse request body to get pin state
+	var body struct {
+		IsPinned bool   `json:"isPinned"`
+		Reason   string `json:"reason"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	mc.moderatePost(c, id, services.ModeratePostInput{
+		IsPinned: &body.IsPinned,
+		Reason:   body.Reason,
+	})
+}
+
+func (mc *ModerationController) moderatePost(c *gin.Context, id string, input services.ModeratePostInput) {
+	user := c.MustGet("user").(models.User)
+
+	post, err := mc.moderationService.ModeratePost(id, user.ID, input)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "post not found":
+			statusCode = http.StatusNotFound
+		case "nothing to change":
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"isPinned": post.IsPinned,
+		"isLocked": post.IsLocked,
+	})
+}
+
+// GetPostModerationHistory lists who pinned / locked a post and why
+func (mc *ModerationController) GetPostModerationHistory(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	events, err := mc.moderationService.GetModerationHistory("post", id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+// ArchiveTopic archives or unarchives a topic, a reason is required
+func (mc *ModerationController) ArchiveTopic(c *gin.Context) {
+	slug := c.Param("slug")
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please provide a valid topic slug"})
+		return
+	}
+
+	var body struct {
+		IsArchived *bool  `json:"isArchived" binding:"required"`
+		Reason     string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please provide isArchived and a reason"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	topic, err := mc.moderationService.SetTopicArchived(slug, user.ID, *body.IsArchived, body.Reason)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "topic not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"topic": topic})
+}
@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/Kk120306/cvwo-2026/backend/models"
 	"github.com/Kk120306/cvwo-2026/backend/services"
@@ -41,6 +43,29 @@ func (pc *PostController) GetAllPosts(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"posts": posts})
 }
 
+// GetHomeFeed returns the posts of followed topics and users, paged with ?cursor= &limit=
+func (pc *PostController) GetHomeFeed(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	page, err := pc.postService.GetHomeFeed(user.ID, c.Query("cursor"), limit)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "invalid cursor" {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
 // Function to get posts under a topic
 func (pc *PostController) GetPostsByTopic(c *gin.Context) {
 	slug := c.Param("slug")
@@ -84,9 +109,12 @@ func (pc *PostController) CreatePost(c *gin.Context) {
 
 	// Parse request body
 	var body struct {
-		Title    string  `json:"title" binding:"required"`
-		Content  string  `json:"content" binding:"required"`
-		ImageUrl *string `json:"imageUrl"`
+		Title     string     `json:"title" binding:"required"`
+		Content   string     `json:"content" binding:"required"`
+		Format    string     `json:"format"` // html (default) or markdown
+		ImageUrl  *string    `json:"imageUrl"`
+		Status    string     `json:"status"`    // draft, scheduled or published (default)
+		PublishAt *time.Time `json:"publishAt"` // RFC 3339, required when scheduled
 	}
 
 	// check if parsing req binds with struct
@@ -113,15 +141,28 @@ func (pc *PostController) CreatePost(c *gin.Context) {
 
 	// Create post through service layer
 	post, err := pc.postService.CreatePost(services.CreatePostInput{
-		Title:    body.Title,
-		Content:  body.Content,
-		TopicID:  topic.ID,
-		AuthorID: user.ID,
-		ImageUrl: body.ImageUrl,
+		Title:     body.Title,
+		Content:   body.Content,
+		Format:    body.Format,
+		TopicID:   topic.ID,
+		AuthorID:  user.ID,
+		ImageUrl:  body.ImageUrl,
+		Status:    body.Status,
+		PublishAt: body.PublishAt,
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "invalid post status", "publishAt must be in the future", "invalid format",
+			"title cannot be empty", "title must be a single line", "title is too long",
+			"content cannot be empty", "content is too long":
+			statusCode = http.StatusBadRequest
+		case "topic is archived", "account is muted", "account is suspended", "account is banned",
+			"you can not mention a user who blocked you", "content was blocked by the content filter":
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{
 			"error": err.Error(),
 		})
 		return
@@ -183,14 +224,20 @@ func (pc *PostController) DeletePost(c *gin.Context) {
 	}
 
 	// check if the user is the author or admin through service layer
+	// other users do not get to know a draft exists
 	user := c.MustGet("user").(models.User)
+	if !pc.postService.IsVisibleTo(&user, post) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "post not found"})
+		return
+	}
 	if !pc.postService.CanUserModifyPost(&user, post) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to delete this post"})
 		return
 	}
 
 	// Delete post through service layer
-	err = pc.postService.DeletePost(post)
+	// Optional ?reason= kept in the audit log when an admin removes someone elses post
+	err = pc.postService.DeletePost(post, &user, c.Query("reason"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -211,9 +258,12 @@ func (pc *PostController) UpdatePost(c *gin.Context) {
 
 	// Parse request body
 	var body struct {
-		Title    string  `json:"title" binding:"required"`
-		Content  string  `json:"content" binding:"required"`
-		ImageURL *string `json:"imageUrl"`
+		Title     string     `json:"title" binding:"required"`
+		Content   string     `json:"content" binding:"required"`
+		Format    string     `json:"format"` // html (default) or markdown
+		ImageURL  *string    `json:"imageUrl"`
+		Status    *string    `json:"status"`    // only while the post is unpublished
+		PublishAt *time.Time `json:"publishAt"` // RFC 3339, required when scheduled
 	}
 
 	// Check if parsing req binds with struct
@@ -243,6 +293,11 @@ func (pc *PostController) UpdatePost(c *gin.Context) {
 	user := userInterface.(models.User)
 
 	// Authorization check through service layer
+	// other users do not get to know a draft exists
+	if !pc.postService.IsVisibleTo(&user, post) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return
+	}
 	if !pc.postService.CanUserModifyPost(&user, post) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to update this post"})
 		return
@@ -250,12 +305,27 @@ func (pc *PostController) UpdatePost(c *gin.Context) {
 
 	// Update post through service layer
 	err = pc.postService.UpdatePost(post, services.UpdatePostInput{
-		Title:    body.Title,
-		Content:  body.Content,
-		ImageURL: body.ImageURL,
+		Title:     body.Title,
+		Content:   body.Content,
+		Format:    body.Format,
+		ImageURL:  body.ImageURL,
+		EditorID:  user.ID,
+		Status:    body.Status,
+		PublishAt: body.PublishAt,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "invalid post status", "publishAt must be in the future", "published posts can not be unpublished",
+			"invalid format", "title cannot be empty", "title must be a single line", "title is too long",
+			"content cannot be empty", "content is too long":
+			statusCode = http.StatusBadRequest
+		case "account is muted", "account is suspended", "account is banned",
+			"you can not mention a user who blocked you", "content was blocked by the content filter",
+			"post is held for moderation":
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -271,44 +341,15 @@ func (pc *PostController) UpdatePost(c *gin.Context) {
 	})
 }
 
-// function that toggles post pins / assumed that user is a admin through middleware
-func (pc *PostController) TogglePinPost(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
-		return
-	}
-
-	// Parse request body to get  pin state
-	var body struct {
-		IsPinned bool `json:"isPinned"`
-	}
-
-	err := c.ShouldBindJSON(&body)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
-		return
-	}
-
-	// Retrieve post through service layer
-	post, err := pc.postService.FindPostByID(id)
-	if err != nil {
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "post not found" {
-			statusCode = http.StatusNotFound
-		}
-		c.JSON(statusCode, gin.H{"error": err.Error()})
-		return
-	}
+// GetDrafts lists the callers own draft and scheduled posts
+func (pc *PostController) GetDrafts(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
 
-	// Update pin status through service layer
-	err = pc.postService.TogglePinPost(post, body.IsPinned)
+	posts, err := pc.postService.GetDrafts(user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"isPinned": post.IsPinned,
-	})
+	c.JSON(http.StatusOK, gin.H{"posts": posts})
 }
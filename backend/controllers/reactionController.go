@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ReactionController handles HTTP requests for emoji reactions
+type ReactionController struct {
+	reactionService *services.ReactionService
+}
+
+// NewReactionController creates a new instance of ReactionController
+func NewReactionController() *ReactionController {
+	return &ReactionController{
+		reactionService: services.NewReactionService(),
+	}
+}
+
+// Structure for toggling a reaction
+type ReactionRequest struct {
+	ReactableID   string `json:"reactableId" binding:"required"`   // ID of the post or comment
+	ReactableType string `json:"reactableType" binding:"required"` // "post" or "comment"
+	Emoji         string `json:"emoji" binding:"required"`         // one of the allowed reactions
+}
+
+// ToggleReaction adds the callers reaction to a post or comment, or removes it if already there
+func (rc *ReactionController) ToggleReaction(c *gin.Context) {
+	var body ReactionRequest
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// Get authenticated user
+	user := c.MustGet("user").(models.User)
+
+	input := services.ReactionInput{
+		ReactableID:   body.ReactableID,
+		ReactableType: body.ReactableType,
+		Emoji:         body.Emoji,
+	}
+
+	if err := rc.reactionService.ValidateReactionInput(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reactions, err := rc.reactionService.ToggleReaction(user.ID, input)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "post not found", "comment not found":
+			statusCode = http.StatusNotFound
+		case "reaction not allowed":
+			statusCode = http.StatusBadRequest
+		case "post is locked", "topic is archived", "account is muted", "account is suspended", "account is banned":
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reactableId":   body.ReactableID,
+		"reactableType": body.ReactableType,
+		"reactions":     reactions,
+	})
+}
+
+// GetReactions returns the reaction summary of a single post or comment
+func (rc *ReactionController) GetReactions(c *gin.Context) {
+	reactableType := c.Param("type") // must be "post" or "comment"
+	reactableID := c.Param("id")
+
+	if reactableType != "post" && reactableType != "comment" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reactable ID or type"})
+		return
+	}
+	if _, err := uuid.Parse(reactableID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reactable ID or type"})
+		return
+	}
+
+	// Optional auth - logged in callers also see which reactions are theirs
+	var userID *string
+	if user, exists := c.Get("user"); exists {
+		id := user.(models.User).ID
+		userID = &id
+	}
+
+	summaries, err := rc.reactionService.GetReactionSummaries(reactableType, []string{reactableID}, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reactableId":   reactableID,
+		"reactableType": reactableType,
+		"reactions":     summaries[reactableID],
+	})
+}
+
+// GetReactionTypes lists the reactions users can pick from
+func (rc *ReactionController) GetReactionTypes(c *gin.Context) {
+	reactionTypes, err := rc.reactionService.GetReactionTypes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reactionTypes": reactionTypes,
+	})
+}
+
+// GetAllReactionTypes lists every reaction including disabled ones - admin only
+func (rc *ReactionController) GetAllReactionTypes(c *gin.Context) {
+	reactionTypes, err := rc.reactionService.GetAllReactionTypes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reactionTypes": reactionTypes,
+	})
+}
+
+// CreateReactionType adds a reaction to the allowed set - admin only
+func (rc *ReactionController) CreateReactionType(c *gin.Context) {
+	var body struct {
+		Emoji    string `json:"emoji" binding:"required"`
+		Name     string `json:"name" binding:"required"`
+		Position int    `json:"position"`
+	}
+
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	reactionType, err := rc.reactionService.CreateReactionType(services.CreateReactionTypeInput{
+		Emoji:    body.Emoji,
+		Name:     body.Name,
+		Position: body.Position,
+		ActorID:  c.MustGet("user").(models.User).ID,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "invalid emoji", "invalid reaction name":
+			statusCode = http.StatusBadRequest
+		case "reaction already exists":
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"reactionType": reactionType,
+	})
+}
+
+// UpdateReactionType renames, reorders or enables / disables a reaction - admin only
+func (rc *ReactionController) UpdateReactionType(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reaction ID"})
+		return
+	}
+
+	var body struct {
+		Name     *string `json:"name"`
+		Position *int    `json:"position"`
+		IsActive *bool   `json:"isActive"`
+	}
+
+	if c.Bind(&body) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	reactionType, err := rc.reactionService.UpdateReactionType(id, services.UpdateReactionTypeInput{
+		Name:     body.Name,
+		Position: body.Position,
+		IsActive: body.IsActive,
+		ActorID:  c.MustGet("user").(models.User).ID,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "reaction not found":
+			statusCode = http.StatusNotFound
+		case "invalid reaction name":
+			statusCode = http.StatusBadRequest
+		case "reaction already exists":
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reactionType": reactionType,
+	})
+}
+
+// DeleteReactionType removes a reaction and every use of it - admin only
+func (rc *ReactionController) DeleteReactionType(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reaction ID"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	err := rc.reactionService.DeleteReactionType(id, user.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "reaction not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reaction deleted successfully",
+	})
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ReadController handles HTTP requests for read tracking
+type ReadController struct {
+	readService *services.ReadService
+}
+
+// NewReadController creates a new instance of ReadController
+func NewReadController() *ReadController {
+	return &ReadController{
+		readService: services.NewReadService(),
+	}
+}
+
+// MarkTopicRead marks every thread in a topic as read for the logged in user
+func (rc *ReadController) MarkTopicRead(c *gin.Context) {
+	slug := c.Param("slug")
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please provide a valid topic slug"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	marked, err := rc.readService.MarkTopicRead(user.ID, slug)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "topic not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"marked": marked})
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RevisionController handles HTTP requests for the edit history of posts and comments
+type RevisionController struct {
+	revisionService *services.RevisionService
+	postService     *services.PostService
+}
+
+// NewRevisionController creates a new instance of RevisionController
+func NewRevisionController() *RevisionController {
+	return &RevisionController{
+		revisionService: services.NewRevisionService(),
+		postService:     services.NewPostService(),
+	}
+}
+
+// GetPostRevisions lists every revision of a post
+func (rc *RevisionController) GetPostRevisions(c *gin.Context) {
+	rc.getRevisions(c, "post")
+}
+
+// GetCommentRevisions lists every revision of a comment
+func (rc *RevisionController) GetCommentRevisions(c *gin.Context) {
+	rc.getRevisions(c, "comment")
+}
+
+// DiffPostRevisions compares two revisions of a post - ?from=1&to=2
+func (rc *RevisionController) DiffPostRevisions(c *gin.Context) {
+	rc.diffRevisions(c, "post")
+}
+
+// DiffCommentRevisions compares two revisions of a comment - ?from=1&to=2
+func (rc *RevisionController) DiffCommentRevisions(c *gin.Context) {
+	rc.diffRevisions(c, "comment")
+}
+
+// RollbackPost restores a post to an earlier revision - assumed that user is a admin through middleware
+// Optional ?reason= is kept in the audit log
+func (rc *RevisionController) RollbackPost(c *gin.Context) {
+	id, version, ok := revisionParams(c)
+	if !ok {
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	err := rc.revisionService.RollbackPost(id, version, user.ID, c.Query("reason"))
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// Reload the post with relationships (Author and Topic) through service layer
+	post, err := rc.postService.ReloadPostWithRelationships(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"post": post})
+}
+
+// RollbackComment restores a comment to an earlier revision - assumed that user is a admin through middleware
+// Optional ?reason= is kept in the audit log
+func (rc *RevisionController) RollbackComment(c *gin.Context) {
+	id, version, ok := revisionParams(c)
+	if !ok {
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	comment, err := rc.revisionService.RollbackComment(id, version, user.ID, c.Query("reason"))
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comment": comment})
+}
+
+func (rc *RevisionController) getRevisions(c *gin.Context, revisableType string) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + revisableType + " ID"})
+		return
+	}
+
+	// Held content is only shown to its author and admins
+	var viewer *models.User
+	if u, exists := c.Get("user"); exists {
+		user := u.(models.User)
+		viewer = &user
+	}
+
+	revisions, err := rc.revisionService.GetRevisions(revisableType, id, viewer)
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+func (rc *RevisionController) diffRevisions(c *gin.Context, revisableType string) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + revisableType + " ID"})
+		return
+	}
+
+	from, fromErr := strconv.Atoi(c.Query("from"))
+	to, toErr := strconv.Atoi(c.Query("to"))
+	if fromErr != nil || toErr != nil || from < 1 || to < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please provide valid from and to versions"})
+		return
+	}
+
+	// Held content is only shown to its author and admins
+	var viewer *models.User
+	if u, exists := c.Get("user"); exists {
+		user := u.(models.User)
+		viewer = &user
+	}
+
+	diff, err := rc.revisionService.DiffRevisions(revisableType, id, from, to, viewer)
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"diff": diff})
+}
+
+// revisionParams reads the :id and :version params shared by the rollback routes
+func revisionParams(c *gin.Context) (string, int, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return "", 0, false
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return "", 0, false
+	}
+
+	return id, version, true
+}
+
+// revisionErrorStatus maps revision service errors to a status code
+func revisionErrorStatus(err error) int {
+	switch err.Error() {
+	case "post not found", "comment not found", "revision not found":
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
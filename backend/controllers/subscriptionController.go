@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// SubscriptionController handles HTTP requests for following and muting topics and following users
+type SubscriptionController struct {
+	subscriptionService *services.SubscriptionService
+}
+
+// NewSubscriptionController creates a new instance of SubscriptionController
+func NewSubscriptionController() *SubscriptionController {
+	return &SubscriptionController{
+		subscriptionService: services.NewSubscriptionService(),
+	}
+}
+
+// SetTopicSubscription follows, mutes or clears a topic for the logged in user
+// mode is "follow", "mute" or "none"
+func (sc *SubscriptionController) SetTopicSubscription(c *gin.Context) {
+	slug := c.Param("slug")
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please provide a valid topic slug"})
+		return
+	}
+
+	var body struct {
+		Mode string `json:"mode" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please provide a mode"})
+		return
+	}
+
+	mode := body.Mode
+	if mode == "none" {
+		mode = ""
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	err := sc.subscriptionService.SetTopicSubscription(user.ID, slug, mode)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "topic not found":
+			statusCode = http.StatusNotFound
+		case "invalid subscription mode":
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"slug": slug,
+		"mode": body.Mode,
+	})
+}
+
+// FollowUser follows the user in the path
+func (sc *SubscriptionController) FollowUser(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	err := sc.subscriptionService.FollowUser(user.ID, c.Param("username"))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "user not found":
+			statusCode = http.StatusNotFound
+		case "you can not follow yourself":
+			statusCode = http.StatusBadRequest
+		case "you can not follow this user":
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"following": true})
+}
+
+// UnfollowUser stops following the user in the path
+func (sc *SubscriptionController) UnfollowUser(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	err := sc.subscriptionService.UnfollowUser(user.ID, c.Param("username"))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "user not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"following": false})
+}
+
+// GetSubscriptions lists what the logged in user follows and has muted
+func (sc *SubscriptionController) GetSubscriptions(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	subscriptions, err := sc.subscriptionService.GetSubscriptions(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subscriptions})
+}
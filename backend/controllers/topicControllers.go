@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 
+	"github.com/Kk120306/cvwo-2026/backend/models"
 	"github.com/Kk120306/cvwo-2026/backend/services"
 	"github.com/gin-gonic/gin"
 )
@@ -19,7 +20,7 @@ func NewTopicController() *TopicController {
 	}
 }
 
-// retrieves all topics available sorted by creation date
+// retrieves all topics in admin order with their post counts and latest activity
 func (tc *TopicController) GetTopics(c *gin.Context) {
 	// Get topics through service layer
 	topics, err := tc.topicService.GetAllTopics()
@@ -36,11 +37,17 @@ func (tc *TopicController) GetTopics(c *gin.Context) {
 	})
 }
 
-// Function that creates a topic and returns the topic object that was created
+// Function that creates a topic and returns the topic object that was created - admin only
 func (tc *TopicController) CreateTopic(c *gin.Context) {
 	// structure of the request body that we need
 	var body struct {
-		Name string `json:"name" binding:"required"`
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+		Icon        string `json:"icon"`
+		Color       string `json:"color"`
+		Rules       string `json:"rules"`      // Markdown
+		ParentSlug  string `json:"parentSlug"` // optional category to nest under
+		Reason      string `json:"reason"`     // optional, kept in the audit log
 	}
 
 	// if parsing the req to body fails, returns non nil, sends bad request
@@ -52,12 +59,27 @@ func (tc *TopicController) CreateTopic(c *gin.Context) {
 	}
 
 	// Create topic through service layer
+	user := c.MustGet("user").(models.User)
 	topic, err := tc.topicService.CreateTopic(services.CreateTopicInput{
-		Name: body.Name,
+		Name:        body.Name,
+		Description: body.Description,
+		Icon:        body.Icon,
+		Color:       body.Color,
+		Rules:       body.Rules,
+		ParentSlug:  body.ParentSlug,
+		ActorID:     user.ID,
+		Reason:      body.Reason,
 	})
 
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		statusCode := http.StatusBadRequest
+		switch {
+		case err.Error() == "a topic with that name already exists":
+			statusCode = http.StatusConflict
+		case !isTopicInputError(err):
+			statusCode = http.StatusInternalServerError
+		}
+		c.JSON(statusCode, gin.H{
 			"error": err.Error(),
 		})
 		return
@@ -81,8 +103,11 @@ func (tc *TopicController) DeleteTopic(c *gin.Context) {
 		return
 	}
 
-	// Delete through service layer
-	err := tc.topicService.DeleteTopic(slug)
+	// Get the admin from middleware
+	user := c.MustGet("user").(models.User)
+
+	// Delete through service layer - optional ?reason= is kept in the audit log
+	err := tc.topicService.DeleteTopic(slug, user.ID, c.Query("reason"))
 	if err != nil {
 		// Determine status code based on error type
 		statusCode := http.StatusInternalServerError
@@ -112,8 +137,15 @@ func (tc *TopicController) UpdateTopic(c *gin.Context) {
 	}
 
 	// Parse the new body that is passed - the new name user wants to set
+	// Metadata fields that are left out stay as they are, parentSlug "" moves it to the top level
 	var body struct {
-		Name string `json:"name" binding:"required"`
+		Name        string  `json:"name" binding:"required"`
+		Description *string `json:"description"`
+		Icon        *string `json:"icon"`
+		Color       *string `json:"color"`
+		Rules       *string `json:"rules"`
+		ParentSlug  *string `json:"parentSlug"`
+		Reason      string  `json:"reason"` // optional, kept in the audit log
 	}
 
 	// Must require empty validation in the frontend
@@ -139,12 +171,27 @@ func (tc *TopicController) UpdateTopic(c *gin.Context) {
 	}
 
 	// Update topic through service layer
+	user := c.MustGet("user").(models.User)
 	err = tc.topicService.UpdateTopic(topic, services.UpdateTopicInput{
-		Name: body.Name,
+		Name:        body.Name,
+		Description: body.Description,
+		Icon:        body.Icon,
+		Color:       body.Color,
+		Rules:       body.Rules,
+		ParentSlug:  body.ParentSlug,
+		ActorID:     user.ID,
+		Reason:      body.Reason,
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		statusCode := http.StatusInternalServerError
+		switch {
+		case err.Error() == "a topic with that name already exists":
+			statusCode = http.StatusConflict
+		case isTopicInputError(err):
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{
 			"error": err.Error(),
 		})
 		return
@@ -155,3 +202,48 @@ func (tc *TopicController) UpdateTopic(c *gin.Context) {
 		"topic": topic,
 	})
 }
+
+// ReorderTopics sets the admin defined order of topics, slugs lists them first to last
+func (tc *TopicController) ReorderTopics(c *gin.Context) {
+	var body struct {
+		Slugs  []string `json:"slugs" binding:"required"`
+		Reason string   `json:"reason"` // optional, kept in the audit log
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Please provide the topic slugs in order",
+		})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	topics, err := tc.topicService.ReorderTopics(body.Slugs, user.ID, body.Reason)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "no topics to reorder", "topic listed more than once", "topic not found":
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"topics": topics,
+	})
+}
+
+// isTopicInputError reports whether err is a validation error of the topic metadata or parent
+func isTopicInputError(err error) bool {
+	switch err.Error() {
+	case "topic name cannot be empty", "topic name must contain letters or numbers", "topic description is too long", "topic icon is too long",
+		"topic color must be a hex color like #1a2b3c", "topic rules are too long",
+		"parent topic not found", "a topic can not be its own parent", "topics can only be nested one level deep":
+		return true
+	}
+	return false
+}
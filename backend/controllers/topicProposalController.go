@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// TopicProposalController handles HTTP requests for topic proposals
+type TopicProposalController struct {
+	proposalService *services.TopicProposalService
+}
+
+// NewTopicProposalController creates a new instance of TopicProposalController
+func NewTopicProposalController() *TopicProposalController {
+	return &TopicProposalController{
+		proposalService: services.NewTopicProposalService(),
+	}
+}
+
+// CreateProposal lets a user propose a new topic
+func (pc *TopicProposalController) CreateProposal(c *gin.Context) {
+	var body struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+		Reason      string `json:"reason"` // why the topic should exist
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please provide a topic name"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	proposal, err := pc.proposalService.CreateProposal(services.CreateProposalInput{
+		Name:        body.Name,
+		Description: body.Description,
+		Reason:      body.Reason,
+		ProposerID:  user.ID,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "topic name cannot be empty", "topic name is too long", "topic description is too long":
+			statusCode = http.StatusBadRequest
+		case "a topic with that name already exists", "that topic has already been proposed":
+			statusCode = http.StatusConflict
+		case "too many pending proposals":
+			statusCode = http.StatusTooManyRequests
+		case "account is muted", "account is suspended", "account is banned":
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"proposal": proposal})
+}
+
+// GetMyProposals lists the proposals of the logged in user and what became of them
+func (pc *TopicProposalController) GetMyProposals(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	proposals, err := pc.proposalService.GetProposalsByUser(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"proposals": proposals})
+}
+
+// GetProposals lists proposals for admins, ?status= defaults to pending and "all" lists everything
+func (pc *TopicProposalController) GetProposals(c *gin.Context) {
+	status := c.DefaultQuery("status", models.ProposalStatusPending)
+	switch status {
+	case models.ProposalStatusPending, models.ProposalStatusApproved, models.ProposalStatusRejected:
+	case "all":
+		status = ""
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proposal status"})
+		return
+	}
+
+	proposals, err := pc.proposalService.GetProposals(status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"proposals": proposals})
+}
+
+// ApproveProposal creates the proposed topic, the admin can set the fields a proposer can not
+func (pc *TopicProposalController) ApproveProposal(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proposal ID"})
+		return
+	}
+
+	// Everything is optional here
+	var body struct {
+		Note       string `json:"note"`
+		Icon       string `json:"icon"`
+		Color      string `json:"color"`
+		Rules      string `json:"rules"`
+		ParentSlug string `json:"parentSlug"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	proposal, topic, err := pc.proposalService.ApproveProposal(id, services.ReviewProposalInput{
+		ReviewerID: user.ID,
+		Note:       body.Note,
+		Icon:       body.Icon,
+		Color:      body.Color,
+		Rules:      body.Rules,
+		ParentSlug: body.ParentSlug,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case err.Error() == "proposal not found":
+			statusCode = http.StatusNotFound
+		case err.Error() == "proposal has already been reviewed", err.Error() == "a topic with that name already exists":
+			statusCode = http.StatusConflict
+		case isTopicInputError(err):
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"proposal": proposal,
+		"topic":    topic,
+	})
+}
+
+// RejectProposal turns a proposal down, a note for the proposer is required
+func (pc *TopicProposalController) RejectProposal(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proposal ID"})
+		return
+	}
+
+	var body struct {
+		Note string `json:"note" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please provide a note"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	proposal, err := pc.proposalService.RejectProposal(id, services.ReviewProposalInput{
+		ReviewerID: user.ID,
+		Note:       body.Note,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "proposal not found":
+			statusCode = http.StatusNotFound
+		case "proposal has already been reviewed":
+			statusCode = http.StatusConflict
+		case "a note is required to reject a proposal":
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"proposal": proposal})
+}
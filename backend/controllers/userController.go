@@ -2,24 +2,31 @@ package controllers
 
 import (
 	"net/http"
+	"net/url"
+	"strconv"
 
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/Kk120306/cvwo-2026/backend/models"
 	"github.com/Kk120306/cvwo-2026/backend/services"
 	"github.com/gin-gonic/gin"
 )
 
 // UserController handles HTTP requests for users
 type UserController struct {
-	userService *services.UserService
+	userService     *services.UserService
+	settingsService *services.SettingsService
 }
 
 // NewUserController creates a new instance of UserController
-func NewUserController() *UserController {
+func NewUserController(awsConfig config.AWSConfig) *UserController {
 	return &UserController{
-		userService: services.NewUserService(),
+		userService:     services.NewUserService(),
+		settingsService: services.NewSettingsService(awsConfig),
 	}
 }
 
-// GetUserProfile retrieves a user's profile with optional posts and comments
+// GetUserProfile retrieves a user's profile with their statistics and karma
+// Old usernames redirect to the profile under the current one
 func (uc *UserController) GetUserProfile(c *gin.Context) {
 	username := c.Param("username")
 	if username == "" {
@@ -27,12 +34,27 @@ func (uc *UserController) GetUserProfile(c *gin.Context) {
 		return
 	}
 
-	// Check query parameters for optional data
-	includePosts := c.Query("posts") == "true"
-	includeComments := c.Query("comments") == "true"
+	// Owner and admins see past the privacy settings
+	var viewer *models.User
+	if u, exists := c.Get("user"); exists {
+		user := u.(models.User)
+		viewer = &user
+	}
 
 	// Get user profile through service layer
-	profile, err := uc.userService.GetUserProfile(username, includePosts, includeComments)
+	profile, err := uc.userService.GetUserProfile(username, viewer)
+	if err != nil && err.Error() == "user not found" {
+		if current, renameErr := uc.userService.FindRenamedUser(username); renameErr == nil {
+			// 302 rather than 301, the old name can be taken by someone else later
+			// relative so it still works behind the /api prefix the proxy strips
+			location := url.PathEscape(current)
+			if c.Request.URL.RawQuery != "" {
+				location += "?" + c.Request.URL.RawQuery
+			}
+			c.Redirect(http.StatusFound, location)
+			return
+		}
+	}
 	if err != nil {
 		// Determine status code based on error type
 		statusCode := http.StatusInternalServerError
@@ -45,3 +67,141 @@ func (uc *UserController) GetUserProfile(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"user": profile})
 }
+
+// GetUserPosts returns a page of a users published posts with vote counts
+// ?sort= new (default), old or top, paging: ?page= &limit=
+func (uc *UserController) GetUserPosts(c *gin.Context) {
+	page, limit, ok := activityPaging(c)
+	if !ok {
+		return
+	}
+
+	var viewer *models.User
+	if u, exists := c.Get("user"); exists {
+		user := u.(models.User)
+		viewer = &user
+	}
+
+	posts, err := uc.userService.GetUserPosts(c.Param("username"), viewer, c.Query("sort"), page, limit)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "user not found":
+			statusCode = http.StatusNotFound
+		case "invalid sort":
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, posts)
+}
+
+// GetUserComments returns a page of a users comments with vote counts, same query parameters as GetUserPosts
+func (uc *UserController) GetUserComments(c *gin.Context) {
+	page, limit, ok := activityPaging(c)
+	if !ok {
+		return
+	}
+
+	var viewer *models.User
+	if u, exists := c.Get("user"); exists {
+		user := u.(models.User)
+		viewer = &user
+	}
+
+	comments, err := uc.userService.GetUserComments(c.Param("username"), viewer, c.Query("sort"), page, limit)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "user not found":
+			statusCode = http.StatusNotFound
+		case "invalid sort":
+			statusCode = http.StatusBadRequest
+		case "comments are hidden":
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+// activityPaging reads ?page= and ?limit= for the profile listings, responding with 400 when they are off
+func activityPaging(c *gin.Context) (int, int, bool) {
+	page, pageErr := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, limitErr := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if pageErr != nil || limitErr != nil || page < 1 || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be at least 1 and limit between 1 and 100"})
+		return 0, 0, false
+	}
+	return page, limit, true
+}
+
+// GetSettings returns the logged in users profile and privacy settings
+func (uc *UserController) GetSettings(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	settings, err := uc.settingsService.GetSettings(user.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "user not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"settings": settings})
+}
+
+// UpdateSettings changes the logged in users profile and privacy settings, missing fields are left as they are
+// avatarImage is the image name from /images/s3Url after uploading, an empty string resets the avatar
+func (uc *UserController) UpdateSettings(c *gin.Context) {
+	var body struct {
+		Username     *string `json:"username"`
+		DisplayName  *string `json:"displayName"`
+		Bio          *string `json:"bio"`
+		AvatarImage  *string `json:"avatarImage"`
+		HideComments *bool   `json:"hideComments"`
+		HideStats    *bool   `json:"hideStats"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid settings"})
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+
+	settings, err := uc.settingsService.UpdateSettings(user.ID, services.UpdateSettingsInput{
+		Username:     body.Username,
+		DisplayName:  body.DisplayName,
+		Bio:          body.Bio,
+		AvatarImage:  body.AvatarImage,
+		HideComments: body.HideComments,
+		HideStats:    body.HideStats,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "user not found":
+			statusCode = http.StatusNotFound
+		case "display name is too long", "display name must be a single line", "bio is too long",
+			"invalid avatar image", "usernames must be 3 to 30 letters, numbers or underscores":
+			statusCode = http.StatusBadRequest
+		case "username is already taken":
+			statusCode = http.StatusConflict
+		case "you can only change your username once every 30 days":
+			statusCode = http.StatusTooManyRequests
+		case "account is muted", "account is suspended", "account is banned":
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"settings": settings})
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/Kk120306/cvwo-2026/backend/models"
 	"github.com/Kk120306/cvwo-2026/backend/services"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // VoteController handles HTTP requests for votes
@@ -52,50 +53,37 @@ func (vc *VoteController) CreateOrUpdateVote(c *gin.Context) {
 		return
 	}
 
-	// Try to find existing vote through service layer
-	vote, err := vc.voteService.FindExistingVote(user.ID, body.VotableID, body.VotableType)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Optional - lets clients retry safely, a repeat with the same key returns the first result
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if len(idempotencyKey) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
 		return
 	}
 
-	if vote == nil {
-		// No vote exists, create new vote through service layer
-		err = vc.voteService.CreateVote(user.ID, input)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-	} else {
-		// Vote exists
-		if vote.VoteType == body.VoteType {
-			// Same vote clicked, remove it through service layer
-			err = vc.voteService.DeleteVote(vote)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-		} else {
-			// Different vote, update it through service layer
-			err = vc.voteService.UpdateVote(vote, body.VoteType)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-		}
-	}
-
-	// Respond with updated counts through service layer
-	voteCounts, err := vc.voteService.GetVoteCountsWithUserVote(body.VotableID, body.VotableType, user.ID)
+	// Create, switch or remove the vote in one transaction through service layer
+	voteCounts, err := vc.voteService.ToggleVote(user.ID, input, idempotencyKey)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// Determine status code based on error type
+		statusCode := http.StatusInternalServerError
+		switch err.Error() {
+		case "post not found", "comment not found":
+			statusCode = http.StatusNotFound
+		case "idempotency key was already used for a different request":
+			statusCode = http.StatusUnprocessableEntity
+		case "post is locked", "topic is archived", "account is suspended", "account is banned":
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Respond with updated counts and the callers vote
 	c.JSON(http.StatusOK, gin.H{
-		"likes":    voteCounts.Likes,
-		"dislikes": voteCounts.Dislikes,
-		"myVote":   voteCounts.MyVote,
+		"votableId":   body.VotableID,
+		"votableType": body.VotableType,
+		"likes":       voteCounts.Likes,
+		"dislikes":    voteCounts.Dislikes,
+		"myVote":      voteCounts.MyVote,
 	})
 }
 
@@ -103,10 +91,11 @@ func (vc *VoteController) CreateOrUpdateVote(c *gin.Context) {
 // Using params as its a func that retrieves info for the client
 func (vc *VoteController) GetVotesCount(c *gin.Context) {
 	votableID := c.Param("id")
-	votableType := c.Query("type") // must be "post" or "comment"
+	votableType := c.Param("type") // must be "post" or "comment"
 
 	// Check if params have valid values
-	if votableID == "" || (votableType != "post" && votableType != "comment") {
+	// the id goes into a uuid column, anything else would be a database error
+	if _, err := uuid.Parse(votableID); err != nil || (votableType != "post" && votableType != "comment") {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid votable ID or type"})
 		return
 	}
@@ -114,7 +103,11 @@ func (vc *VoteController) GetVotesCount(c *gin.Context) {
 	// Get vote counts through service layer
 	likes, dislikes, err := vc.voteService.GetVoteCounts(votableID, votableType)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		statusCode := http.StatusInternalServerError
+		if err.Error() == votableType+" not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -1,11 +1,12 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"github.com/Kk120306/cvwo-2026/backend/helpers"
+
+	"github.com/Kk120306/cvwo-2026/backend/config"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"os"
 )
 
 var DB *gorm.DB
@@ -13,21 +14,51 @@ var DB *gorm.DB
 // Using GORM to connect to the database
 // Using PostgreSQL as the database - hosted rn on Neon
 // https://gorm.io/docs/connecting_to_the_database.html
-func ConnectToDb() {
-	helpers.LoadEnvVariables()
-
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_SSLMODE"),
-	)
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+func ConnectToDb(cfg config.DatabaseConfig) error {
+	db, err := gorm.Open(postgres.Open(cfg.URL), &gorm.Config{})
 	if err != nil {
-		panic("failed to connect database")
+		return fmt.Errorf("failed to connect database: %w", err)
+	}
+
+	// Apply the pool limits to the generic database interface GORM wraps
+	// https://gorm.io/docs/generic_interface.html#Connection-Pool
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to configure database pool: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	DB = db
+	return nil
+}
+
+// Ping checks that the database can still be reached
+func Ping(ctx context.Context) error {
+	if DB == nil {
+		return fmt.Errorf("database not connected")
 	}
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
+
+// Close closes the connection pool - called once the server has drained
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
 }
@@ -1,14 +1,141 @@
 package database
 
 import (
+	"sync/atomic"
+
 	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm"
 )
 
+// set once every model has migrated, readiness reports failing until then
+var migrated atomic.Bool
+
 // https://gorm.io/docs/index.html -- Migrating the schema
-func PushDb() {
-	DB.AutoMigrate(&models.User{})
-	DB.AutoMigrate(&models.Topic{})
-	DB.AutoMigrate(&models.Post{})
-	DB.AutoMigrate(&models.Comment{})
-	DB.AutoMigrate(&models.Vote{})
+func PushDb() error {
+	// The vote counter columns are new - existing rows need them filled in once they exist
+	backfillCounters := !DB.Migrator().HasColumn(&models.Post{}, "LikeCount")
+	// Same for the publish time feeds are ordered by
+	backfillPublishedAt := !DB.Migrator().HasColumn(&models.Post{}, "PublishedAt")
+	// and the username audit entries keep of their actor
+	backfillActorUsernames := !DB.Migrator().HasColumn(&models.AuditLog{}, "ActorUsername")
+
+	err := DB.AutoMigrate(
+		&models.User{},
+		&models.Topic{},
+		&models.Post{},
+		&models.Comment{},
+		&models.Vote{},
+		&models.IdempotencyKey{},
+		&models.ReactionType{},
+		&models.Reaction{},
+		&models.Revision{},
+		&models.ModerationEvent{},
+		&models.AuditLog{},
+		&models.TopicProposal{},
+		&models.TopicSubscription{},
+		&models.UserFollow{},
+		&models.Bookmark{},
+		&models.ReadMarker{},
+		&models.UsernameHistory{},
+		&models.UserBlock{},
+		&models.Conversation{},
+		&models.ConversationParticipant{},
+		&models.Message{},
+		&models.LinkPreview{},
+		&models.FilterRule{},
+		&models.FilterHit{},
+		&models.Upload{},
+	)
+	if err != nil {
+		return err
+	}
+
+	if backfillCounters {
+		for votableType := range counterTables {
+			if err := RecountVoteCounters(DB, votableType, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Every post before drafts existed was published when it was created
+	if backfillPublishedAt {
+		err := DB.Model(&models.Post{}).
+			Where("published_at IS NULL AND status = ?", models.PostStatusPublished).
+			UpdateColumn("published_at", gorm.Expr("created_at")).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Filling in the new column once, raw SQL as the model refuses updates to the log
+	if backfillActorUsernames {
+		err := DB.Exec(`
+			UPDATE audit_logs SET actor_username = users.username
+			FROM users WHERE users.id = audit_logs.actor_id
+		`).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// The actor used to be a foreign key, which would stop their account from ever being deleted
+	if DB.Migrator().HasConstraint(&models.AuditLog{}, "fk_audit_logs_actor") {
+		if err := DB.Migrator().DropConstraint(&models.AuditLog{}, "fk_audit_logs_actor"); err != nil {
+			return err
+		}
+	}
+
+	if err := seedReactionTypes(); err != nil {
+		return err
+	}
+
+	if err := seedDeletedUser(); err != nil {
+		return err
+	}
+
+	migrated.Store(true)
+	return nil
+}
+
+// Migrated reports whether PushDb has completed successfully
+func Migrated() bool {
+	return migrated.Load()
+}
+
+// default reaction set, only inserted while the table is empty so admin changes are kept
+var defaultReactionTypes = []models.ReactionType{
+	{Emoji: "👍", Name: "thumbs_up", Position: 0},
+	{Emoji: "❤️", Name: "heart", Position: 1},
+	{Emoji: "😂", Name: "joy", Position: 2},
+	{Emoji: "😮", Name: "open_mouth", Position: 3},
+	{Emoji: "😢", Name: "cry", Position: 4},
+	{Emoji: "🎉", Name: "tada", Position: 5},
+}
+
+// seedReactionTypes fills in the default reaction set on a fresh database
+func seedReactionTypes() error {
+	var count int64
+	if err := DB.Model(&models.ReactionType{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	// copy so the hook generated IDs do not end up in the package level slice
+	reactionTypes := make([]models.ReactionType, len(defaultReactionTypes))
+	copy(reactionTypes, defaultReactionTypes)
+
+	return DB.Create(&reactionTypes).Error
+}
+
+// seedDeletedUser creates the placeholder that content of deleted accounts is moved to
+// Raw SQL since the BeforeCreate hook would replace the fixed id
+func seedDeletedUser() error {
+	return DB.Exec(`
+		INSERT INTO users (id, username, status, status_reason, created_at, updated_at)
+		VALUES (?, ?, ?, 'placeholder for deleted accounts', NOW(), NOW())
+		ON CONFLICT (id) DO NOTHING
+	`, models.DeletedUserID, models.DeletedUserUsername, models.UserStatusBanned).Error
 }
@@ -205,4 +205,7 @@ func seedVotes(db *gorm.DB) {
 			}
 		}
 	}
+
+	// Votes were inserted directly so bring the stored counters in line
+	RecountVoteCounters(db, "post", nil)
 }
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// votable types and the tables that store their vote counters
+var counterTables = map[string]string{
+	"post":    "posts",
+	"comment": "comments",
+}
+
+// RecountVoteCounters recomputes like_count, dislike_count and score from the votes table
+// ids limits it to those rows, nil recounts every row of the type
+// One UPDATE statement so every row is recounted against the same snapshot of votes
+func RecountVoteCounters(db *gorm.DB, votableType string, ids []string) error {
+	table := counterTables[votableType]
+
+	filter := ""
+	args := []interface{}{votableType}
+	if ids != nil {
+		if len(ids) == 0 {
+			return nil
+		}
+		filter = "WHERE t2.id IN ?"
+		args = append(args, ids)
+	}
+
+	return db.Exec(`
+		UPDATE `+table+` AS t
+		SET like_count = c.likes, dislike_count = c.dislikes, score = c.likes - c.dislikes
+		FROM (
+			SELECT t2.id,
+				COUNT(v.id) FILTER (WHERE v.vote_type = 'like') AS likes,
+				COUNT(v.id) FILTER (WHERE v.vote_type = 'dislike') AS dislikes
+			FROM `+table+` AS t2
+			LEFT JOIN votes AS v ON v.votable_id = t2.id AND v.votable_type = ?
+			`+filter+`
+			GROUP BY t2.id
+		) AS c
+		WHERE t.id = c.id
+	`, args...).Error
+}
@@ -3,26 +3,20 @@ package helpers
 import (
 	"context"
 	"fmt"
-	"os"
 	"time"
 
+	"github.com/Kk120306/cvwo-2026/backend/config"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
 	"github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
 )
 
 // function invalidates Cloudfront cache for image
-func InvalidateCloudFrontCache(imageName string) error {
-
-	cloudfrontDistributionId := os.Getenv("CLOUDFRONT_DISTRIBUTION_ID")
+func InvalidateCloudFrontCache(cfg config.AWSConfig, imageName string) error {
 	ctx := context.Background()
 
-	// Load AWS config
-	cfg, err := config.LoadDefaultConfig(
-		ctx,
-		config.WithRegion(region), // Uses the region from s3.go
-	)
+	// Load AWS config - shared with s3.go
+	awsCfg, err := loadAWSConfig(ctx, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -32,14 +26,14 @@ func InvalidateCloudFrontCache(imageName string) error {
 	// https://www.youtube.com/watch?v=lZAGIy1e3JA&t=8s
 
 	// Create CloudFront client
-	cfClient := cloudfront.NewFromConfig(cfg)
+	cfClient := cloudfront.NewFromConfig(awsCfg)
 
 	// Create invalidation and makes sure its unique by combining image name and time
 	callerReference := fmt.Sprintf("%s-%d", imageName, time.Now().Unix())
 
 	// Invalidating the specific image
 	_, err = cfClient.CreateInvalidation(ctx, &cloudfront.CreateInvalidationInput{
-		DistributionId: aws.String(cloudfrontDistributionId),
+		DistributionId: aws.String(cfg.CloudFrontDistribution),
 		InvalidationBatch: &types.InvalidationBatch{ // Define the invalidation batch
 			CallerReference: aws.String(callerReference), // unique ID of the req
 			Paths: &types.Paths{
@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Cursors mark where a page of a feed ended - the sort time and id of the last item
+// Keyset paging stays fast however deep the client scrolls, unlike OFFSET
+
+// EncodeCursor turns the last item of a page into an opaque cursor
+func EncodeCursor(at time.Time, id string) string {
+	raw := at.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor reads back a cursor made by EncodeCursor
+func DecodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", errors.New("invalid cursor")
+	}
+
+	at, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return time.Time{}, "", errors.New("invalid cursor")
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return time.Time{}, "", errors.New("invalid cursor")
+	}
+
+	return t, id, nil
+}
@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -18,3 +19,17 @@ func GenerateSlug(name string) string {
 
 	return slug
 }
+
+// UniqueSlug returns slug if it is free, otherwise slug with the lowest -2, -3... suffix that is not in taken
+func UniqueSlug(slug string, taken []string) string {
+	used := make(map[string]bool, len(taken))
+	for _, t := range taken {
+		used[t] = true
+	}
+
+	candidate := slug
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s-%d", slug, n)
+	}
+	return candidate
+}
@@ -0,0 +1,139 @@
+package helpers
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// HTMLDiff compares two pieces of sanitized HTML word by word and returns the new version
+// with removed text wrapped in <del> and added text wrapped in <ins>
+// Tags are never split or wrapped - the markup of the new version is kept and removed tags are dropped
+// so the result can be rendered like any other post content
+func HTMLDiff(oldHTML, newHTML string) string {
+	oldTokens := tokenizeHTML(oldHTML)
+	newTokens := tokenizeHTML(newHTML)
+
+	// diffmatchpatch diffs runes, so give every distinct token its own rune and diff those
+	// same trick as its DiffLinesToRunes https://github.com/sergi/go-diff
+	tokenRunes := map[string]rune{}
+	var runeTokens []string
+	toRunes := func(tokens []string) []rune {
+		runes := make([]rune, len(tokens))
+		for i, token := range tokens {
+			r, ok := tokenRunes[token]
+			if !ok {
+				r = indexToRune(len(runeTokens))
+				tokenRunes[token] = r
+				runeTokens = append(runeTokens, token)
+			}
+			runes[i] = r
+		}
+		return runes
+	}
+	oldRunes := toRunes(oldTokens)
+	newRunes := toRunes(newTokens)
+
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMainRunes(oldRunes, newRunes, false)
+
+	var out strings.Builder
+	for _, d := range diffs {
+		tokens := make([]string, 0, len(d.Text))
+		for _, r := range d.Text {
+			tokens = append(tokens, runeTokens[runeToIndex(r)])
+		}
+
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			for _, token := range tokens {
+				out.WriteString(token)
+			}
+		case diffmatchpatch.DiffInsert:
+			writeMarked(&out, tokens, "ins", true)
+		case diffmatchpatch.DiffDelete:
+			writeMarked(&out, tokens, "del", false)
+		}
+	}
+
+	return out.String()
+}
+
+// TextDiff is HTMLDiff for plain text such as titles, the text is escaped first
+func TextDiff(oldText, newText string) string {
+	return HTMLDiff(html.EscapeString(oldText), html.EscapeString(newText))
+}
+
+// writeMarked wraps runs of text tokens in the given tag
+// Tags inside the run are written between the runs when keepTags is set and dropped otherwise
+func writeMarked(out *strings.Builder, tokens []string, tag string, keepTags bool) {
+	open := false
+	for _, token := range tokens {
+		if isTag(token) {
+			if open {
+				out.WriteString("</" + tag + ">")
+				open = false
+			}
+			if keepTags {
+				out.WriteString(token)
+			}
+			continue
+		}
+		if !open {
+			out.WriteString("<" + tag + ">")
+			open = true
+		}
+		out.WriteString(token)
+	}
+	if open {
+		out.WriteString("</" + tag + ">")
+	}
+}
+
+// tokenizeHTML splits HTML into whole tags, runs of whitespace and words
+func tokenizeHTML(s string) []string {
+	var tokens []string
+	for len(s) > 0 {
+		var n int
+		switch {
+		case s[0] == '<':
+			n = strings.IndexByte(s, '>') + 1
+			if n == 0 {
+				n = len(s) // unterminated tag, take the rest
+			}
+		case unicode.IsSpace(rune(s[0])):
+			n = strings.IndexFunc(s, func(r rune) bool { return !unicode.IsSpace(r) })
+		default:
+			n = strings.IndexFunc(s, func(r rune) bool { return r == '<' || unicode.IsSpace(r) })
+		}
+		if n <= 0 {
+			n = len(s)
+		}
+		tokens = append(tokens, s[:n])
+		s = s[n:]
+	}
+	return tokens
+}
+
+func isTag(token string) bool {
+	return strings.HasPrefix(token, "<")
+}
+
+// indexToRune maps a token index to a valid rune, skipping 0 and the surrogate range
+// which would not survive the string conversions inside diffmatchpatch
+func indexToRune(i int) rune {
+	r := rune(i + 1)
+	if r >= 0xD800 {
+		r += 0x800
+	}
+	return r
+}
+
+func runeToIndex(r rune) int {
+	if r >= 0xE000 {
+		r -= 0x800
+	}
+	return int(r) - 1
+}
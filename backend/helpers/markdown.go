@@ -0,0 +1,29 @@
+package helpers
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// https://github.com/yuin/goldmark
+// The GFM extensions - tables, strikethrough, task lists and autolinking of bare urls
+// Table alignment goes in the align attribute as the sanitizer drops style
+// Raw HTML in the source is left out, the WithUnsafe option is what would let it through
+// Fenced code gets class="language-x", the convention client side highlighters like highlight.js look for
+var markdown = goldmark.New(goldmark.WithExtensions(
+	extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+	extension.Strikethrough,
+	extension.Linkify,
+	extension.TaskList,
+))
+
+// RenderMarkdown renders Markdown to HTML, the result still has to be sanitized
+func RenderMarkdown(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,28 @@
+package helpers
+
+import (
+	"regexp"
+)
+
+// @username, not preceded by a letter so email addresses do not count
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_]{3,30})`)
+
+// the most mentions looked at in one piece of content
+const maxMentions = 50
+
+// ExtractMentions returns the usernames mentioned in content, each once
+func ExtractMentions(content string) []string {
+	seen := map[string]bool{}
+	var usernames []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		if seen[match[1]] {
+			continue
+		}
+		seen[match[1]] = true
+		usernames = append(usernames, match[1])
+		if len(usernames) == maxMentions {
+			break
+		}
+	}
+	return usernames
+}
@@ -4,38 +4,48 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"io"
 	"log"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/Kk120306/cvwo-2026/backend/config"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
-// config values - inside url values so no need to hide in env
-const (
-	region     = "ap-southeast-2"
-	bucketName = "direct-upload-s3-cvwo"
-)
+// loadAWSConfig builds the SDK config from the app config
+// Falls back to the default credential chain when no keys are configured (e.g. instance roles)
+func loadAWSConfig(ctx context.Context, cfg config.AWSConfig) (aws.Config, error) {
+	opts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(cfg.Region),
+	}
+	if cfg.AccessKeyID != "" && cfg.SecretAccessKey != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		))
+	}
+
+	return awsconfig.LoadDefaultConfig(ctx, opts...)
+}
 
-// GenerateUploadURL creates a presigned S3 PUT URL
+// GenerateUploadURL creates a presigned S3 PUT URL and returns it with the image name it uploads to
 // https://ronen-niv.medium.com/aws-s3-handling-presigned-urls-2718ab247d57
-func GenerateUploadURL() (string, error) {
+func GenerateUploadURL(cfg config.AWSConfig) (string, string, error) {
 	// Create base context
 	ctx := context.Background()
 
-	// Load AWS config from environment variables
-	cfg, err := config.LoadDefaultConfig(
-		ctx,
-		config.WithRegion(region),
-	)
-
+	// Load AWS config
+	awsCfg, err := loadAWSConfig(ctx, cfg)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	// Create S3 client
-	client := s3.NewFromConfig(cfg)
+	client := s3.NewFromConfig(awsCfg)
 
 	// Create presigner - wrapping client and generating signed url so s3 uploads possile from frontend.
 	presigner := s3.NewPresignClient(client)
@@ -43,7 +53,7 @@ func GenerateUploadURL() (string, error) {
 	// Generate random image name
 	randomBytes := make([]byte, 16)
 	if _, err := rand.Read(randomBytes); err != nil {
-		return "", err
+		return "", "", err
 	}
 	imageName := hex.EncodeToString(randomBytes)
 	// using crypt to secure random names
@@ -51,47 +61,105 @@ func GenerateUploadURL() (string, error) {
 	// Create e url with embedded credential
 	// make sure it expires after 60 seconds
 	req, err := presigner.PresignPutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(bucketName),
+		Bucket: aws.String(cfg.Bucket),
 		Key:    aws.String(imageName),
 	}, s3.WithPresignExpires(60*time.Second))
 
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	return req.URL, nil
+	return req.URL, imageName, nil
 }
 
 // function to delete image from s3 and also invalidate cloudfront cache
-func DeleteImage(imageName string) error {
+func DeleteImage(cfg config.AWSConfig, imageName string) error {
 	// generate context
 	ctx := context.Background()
 
 	// Load AWS config
-	cfg, err := config.LoadDefaultConfig(
-		ctx,
-		config.WithRegion(region),
-	)
+	awsCfg, err := loadAWSConfig(ctx, cfg)
 	if err != nil {
 		return err
 	}
 
 	// Create S3 client
-	client := s3.NewFromConfig(cfg)
+	client := s3.NewFromConfig(awsCfg)
 
 	// Delete the object
 	_, err = client.DeleteObject(ctx, &s3.DeleteObjectInput{
-		Bucket: aws.String(bucketName),
+		Bucket: aws.String(cfg.Bucket),
 		Key:    aws.String(imageName),
 	})
+	if err != nil {
+		return err
+	}
 
 	// Invalidate cloudfront cache
-	err = InvalidateCloudFrontCache(imageName)
+	err = InvalidateCloudFrontCache(cfg, imageName)
 	if err != nil {
 		// Log the error but don't fail the request since S3 deletion succeeded
 		log.Printf("CloudFront invalidation error: %v", err)
 		// Cache can expire either ways so no need to really return error
 	}
 
-	return err
+	return nil
+}
+
+// OpenImage streams an uploaded image out of S3, the caller closes it
+func OpenImage(cfg config.AWSConfig, imageName string) (io.ReadCloser, error) {
+	ctx := context.Background()
+
+	// Load AWS config
+	awsCfg, err := loadAWSConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create S3 client
+	client := s3.NewFromConfig(awsCfg)
+
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(cfg.Bucket),
+		Key:    aws.String(imageName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Body, nil
+}
+
+// uploads get 16 random bytes as hex for a name, see GenerateUploadURL
+var imageNamePattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// IsImageName reports whether name could have been handed out by GenerateUploadURL
+func IsImageName(name string) bool {
+	return imageNamePattern.MatchString(name)
+}
+
+// ImageURL is where an uploaded image is served from
+func ImageURL(cfg config.AWSConfig, imageName string) string {
+	return strings.TrimRight(cfg.ImageBaseURL, "/") + "/" + imageName
+}
+
+// UploadedImageName returns the image name when the URL points at one of our uploads
+// The default avatar lives under the same base URL but is not an upload, so it never matches
+func UploadedImageName(cfg config.AWSConfig, imageURL string) (string, bool) {
+	imageName, ok := strings.CutPrefix(imageURL, strings.TrimRight(cfg.ImageBaseURL, "/")+"/")
+	if !ok || !IsImageName(imageName) {
+		return "", false
+	}
+	return imageName, true
+}
+
+// UploadedImageNames finds every upload referenced in a piece of content, images can be embedded in posts
+func UploadedImageNames(cfg config.AWSConfig, content string) []string {
+	pattern := regexp.MustCompile(regexp.QuoteMeta(strings.TrimRight(cfg.ImageBaseURL, "/")+"/") + `([0-9a-f]{32})`)
+
+	var names []string
+	for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+		names = append(names, match[1])
+	}
+	return names
 }
@@ -9,9 +9,11 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Kk120306/cvwo-2026/backend/cache"
 	"github.com/Kk120306/cvwo-2026/backend/config"
 	"github.com/Kk120306/cvwo-2026/backend/database"
 	"github.com/Kk120306/cvwo-2026/backend/routes"
+	"github.com/Kk120306/cvwo-2026/backend/services"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -20,19 +22,12 @@ import (
 type App struct {
 	Config *config.Config
 	Router *gin.Engine
+	Health *services.HealthService
 }
 
 // New creates a new application instance
-func New() *App {
-	// Load configuration
-	cfg := config.Load()
-
-	// Validate configuration
-	err := cfg.Validate()
-	if err != nil {
-		log.Fatal("Invalid configuration:", err)
-	}
-
+// cfg is the already loaded and validated configuration, see config.Load
+func New(cfg *config.Config) *App {
 	// Set Gin mode based on environment
 	if cfg.Server.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -44,16 +39,29 @@ func New() *App {
 	return &App{
 		Config: cfg,
 		Router: router,
+		Health: services.NewHealthService(),
 	}
 }
 
 // Initialize sets up the application
 func (a *App) Initialize() {
 	// Connect to database
-	database.ConnectToDb()
+	err := database.ConnectToDb(a.Config.Database)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Push database migrations
-	database.PushDb()
+	// Set up the response cache
+	err = cache.Init(a.Config.Cache)
+	if err != nil {
+		log.Fatal("Failed to set up cache:", err)
+	}
+
+	// Push database migrations - readiness keeps failing if this does not succeed
+	err = database.PushDb()
+	if err != nil {
+		log.Println("Database migration failed:", err)
+	}
 
 	// Uncomment for seeding
 	// database.Seed()
@@ -82,13 +90,18 @@ func (a *App) setupCORS() {
 // registers all application routes
 func (a *App) setupRoutes() {
 	// Setting all the routes
-	routes.AuthRoutes(a.Router)
-	routes.TopicRoutes(a.Router)
-	routes.PostsRoutes(a.Router)
-	routes.VoteRoutes(a.Router)
-	routes.CommentRoutes(a.Router)
-	routes.ImageRoutes(a.Router)
-	routes.UserRoutes(a.Router)
+	routes.HealthRoutes(a.Router, a.Health)
+	routes.AuthRoutes(a.Router, a.Config)
+	routes.TopicRoutes(a.Router, a.Config)
+	routes.PostsRoutes(a.Router, a.Config)
+	routes.VoteRoutes(a.Router, a.Config)
+	routes.ReactionRoutes(a.Router, a.Config)
+	routes.BookmarkRoutes(a.Router, a.Config)
+	routes.CommentRoutes(a.Router, a.Config)
+	routes.ImageRoutes(a.Router, a.Config)
+	routes.UserRoutes(a.Router, a.Config)
+	routes.MessageRoutes(a.Router, a.Config)
+	routes.AdminRoutes(a.Router, a.Config)
 }
 
 // Run starts the application server
@@ -98,6 +111,19 @@ func (a *App) Run() {
 		Addr:    "0.0.0.0:" + a.Config.Server.Port,
 		Handler: a.Router,
 	}
+	// Shutdown does not wait on open message streams, end them so they do not hold it up
+	srv.RegisterOnShutdown(services.CloseMessageStreams)
+
+	// Background publishing of scheduled posts, stopped before the pool closes
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		services.NewPublishScheduler(a.Config.Scheduler.PublishInterval).Run(schedulerCtx)
+	}()
+
+	// Background fetching of link previews for new posts and comments
+	services.StartLinkPreviews(a.Config.LinkPreview)
 
 	// Start server in a goroutine
 	// ensures that server dosent block graceful shutdown handling
@@ -121,13 +147,30 @@ func (a *App) Run() {
 	<-quit
 	log.Println("Shutting down server...")
 
-	// The context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Flip readiness to failing first and give probes time to notice
+	// before we stop accepting new connections
+	a.Health.StartDraining()
+	time.Sleep(a.Config.Server.DrainDelay)
+
+	// The context is used to inform the server how long it has to finish
+	// the requests it is currently handling
+	ctx, cancel := context.WithTimeout(context.Background(), a.Config.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		log.Println("Server forced to shutdown:", err)
+	}
+
+	stopScheduler()
+	<-schedulerDone
+	services.StopLinkPreviews()
+
+	// Only close the pool once no handler or job can still be using it
+	if err := database.Close(); err != nil {
+		log.Println("Failed to close database pool:", err)
+	}
+	if err := cache.Close(); err != nil {
+		log.Println("Failed to close cache:", err)
 	}
 
 	log.Println("Server exited")
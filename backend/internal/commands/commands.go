@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/Kk120306/cvwo-2026/backend/config"
+)
+
+// usage lists the available commands, printed for unknown or missing commands
+const usage = `usage:
+  main                               start the server
+  main config print [--redacted]     print the effective configuration
+  main reconcile-votes [--dry-run]   recount stored vote counters and report drift`
+
+// Run dispatches a CLI command e.g. `./main config print --redacted`
+// args excludes the binary name
+func Run(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no command given\n%s", usage)
+	}
+
+	switch args[0] {
+	case "config":
+		return runConfig(cfg, args[1:])
+	case "reconcile-votes":
+		return runReconcileVotes(cfg, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
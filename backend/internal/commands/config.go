@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/goccy/go-yaml"
+)
+
+// runConfig handles `config <subcommand>`
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("unknown config command\n%s", usage)
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redacted := flags.Bool("redacted", false, "mask secrets such as the JWT secret and database password")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	return printConfig(cfg, *redacted)
+}
+
+// printConfig writes the effective config (defaults + file + env) as YAML
+// Validation problems are reported on stderr so the output can still be piped into a file
+func printConfig(cfg *config.Config, redacted bool) error {
+	if redacted {
+		cfg = cfg.Redacted()
+	}
+
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	fmt.Fprint(os.Stdout, string(out))
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "\nconfiguration is invalid:\n%v\n", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+)
+
+// runReconcileVotes handles `reconcile-votes [--dry-run]`
+// Recomputes the stored vote counters from the votes table and prints every drift it found
+func runReconcileVotes(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("reconcile-votes", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only report drift, do not fix it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	if err := database.ConnectToDb(cfg.Database); err != nil {
+		return err
+	}
+	defer database.Close()
+
+	drifts, err := services.NewVoteService().ReconcileCounters(*dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, drift := range drifts {
+		fmt.Printf("%s %s: stored %d/%d (score %d), votes table %d/%d\n",
+			drift.VotableType, drift.VotableID,
+			drift.StoredLikes, drift.StoredDislikes, drift.StoredScore,
+			drift.ActualLikes, drift.ActualDislikes,
+		)
+	}
+
+	switch {
+	case len(drifts) == 0:
+		fmt.Println("vote counters are consistent")
+	case *dryRun:
+		fmt.Printf("%d drifted counters found (dry run, nothing changed)\n", len(drifts))
+	default:
+		fmt.Printf("%d drifted counters fixed\n", len(drifts))
+	}
+
+	return nil
+}
@@ -1,27 +1,49 @@
 package main
 
 import (
-	"github.com/Kk120306/cvwo-2026/backend/internal/app"
 	"log"
 	"os"
+
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/Kk120306/cvwo-2026/backend/internal/app"
+	"github.com/Kk120306/cvwo-2026/backend/internal/commands"
 )
 
 // CompileDaemon --command="./backend"
 // Main entry point for the application
 func main() {
 	log.SetOutput(os.Stdout)
-    log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
-    
-    log.Println("========================================")
-    log.Println("APPLICATION STARTING")
-    log.Println("========================================")
+	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+
+	// Load configuration - the single source every package gets injected with
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	// Anything after the binary name is a CLI command instead of starting the server
+	if len(os.Args) > 1 {
+		if err := commands.Run(cfg, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Validate configuration - reports every problem at once
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	log.Println("========================================")
+	log.Println("APPLICATION STARTING")
+	log.Println("========================================")
 	// Create new application instance
-	application := app.New()
+	application := app.New(cfg)
 
 	log.Println("Calling app.Initialize()...")
 	// Initialize application
 	application.Initialize()
-    log.Println("app.Initialize() COMPLETED")
+	log.Println("app.Initialize() COMPLETED")
 
 	log.Println("Calling app.Run()...")
 	// Run server
@@ -1,18 +1,31 @@
 package middleware
 
 import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Kk120306/cvwo-2026/backend/config"
 	"github.com/Kk120306/cvwo-2026/backend/database"
 	"github.com/Kk120306/cvwo-2026/backend/models"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
-	"log"
-	"net/http"
-	"os"
-	"time"
 )
 
+// AuthMiddleware holds what the auth middlewares need to validate tokens
+type AuthMiddleware struct {
+	jwtConfig config.JWTConfig
+}
+
+// NewAuthMiddleware creates a new instance of AuthMiddleware
+func NewAuthMiddleware(jwtConfig config.JWTConfig) *AuthMiddleware {
+	return &AuthMiddleware{
+		jwtConfig: jwtConfig,
+	}
+}
+
 // Middleware to check if the user is authenticated thorugh cookies with JWT.
-func CheckAuth(c *gin.Context) {
+func (m *AuthMiddleware) CheckAuth(c *gin.Context) {
 	// Get the cookie in the request
 	tokenString, err := c.Cookie("Authorization")
 	if err != nil || tokenString == "" {
@@ -20,39 +33,61 @@ func CheckAuth(c *gin.Context) {
 		return
 	}
 
+	user, err := m.userFromToken(tokenString)
+	if err != nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	// The user is loaded on every request so a ban or suspension cuts off existing sessions straight away
+	switch user.EffectiveStatus(time.Now()) {
+	case models.UserStatusBanned:
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "account is banned"})
+		return
+	case models.UserStatusSuspended:
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "account is suspended",
+			"until": user.StatusUntil,
+		})
+		return
+	}
+
+	// Attach the user to the request context
+	c.Set("user", *user)
+
+	c.Next()
+}
+
+// userFromToken validates the JWT and loads the user it was issued for
+func (m *AuthMiddleware) userFromToken(tokenString string) (*models.User, error) {
 	// https://pkg.go.dev/github.com/golang-jwt/jwt/v5#example-Parse-Hmac
 	// validating the token - Taken from the documentation above
+	// Parse also rejects expired tokens through the exp claim
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		return []byte(os.Getenv("SECRET")), nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+		return []byte(m.jwtConfig.Secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
 	if err != nil {
-		log.Fatal(err)
-	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok { //  If we have access
-		// Check the age
-		if float64(time.Now().Unix()) > claims["exp"].(float64) {
-			c.AbortWithStatus(http.StatusUnauthorized)
-		}
-
-		// Now find the user from the token
-		// https://gorm.io/docs/query.html - refer to retrieving by primary key
-		userID := claims["sub"].(string) // extracting userID from token
-		var user models.User
-		// https://gorm.io/docs/error_handling.html - .Error, cant just get err as second return value
-		err := database.DB.First(&user, "id = ?", userID).Error
-		if err != nil {
-			c.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
-
-		// Attach the user to the request context
-		c.Set("user", user)
-
-		c.Next()
-	} else { // If we don't have access
-		c.AbortWithStatus(http.StatusUnauthorized)
+		return nil, err
 	}
 
-	c.Next()
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+
+	// Now find the user from the token
+	// https://gorm.io/docs/query.html - refer to retrieving by primary key
+	userID, err := claims.GetSubject() // extracting userID from token
+	if err != nil || userID == "" {
+		return nil, errors.New("invalid token subject")
+	}
+
+	var user models.User
+	// https://gorm.io/docs/error_handling.html - .Error, cant just get err as second return value
+	err = database.DB.First(&user, "id = ?", userID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
 }
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag buffers a GET response, tags it with a hash of the body and answers
+// 304 Not Modified when the client already holds the same version (If-None-Match)
+// https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/ETag
+func ETag(c *gin.Context) {
+	if c.Request.Method != http.MethodGet {
+		c.Next()
+		return
+	}
+
+	// Swap in a writer that holds the body back until we know the tag
+	writer := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+	c.Writer = writer
+	c.Next()
+	c.Writer = writer.ResponseWriter
+
+	body := writer.body.Bytes()
+
+	// Only successful responses get tagged, anything else goes out as is
+	if writer.status != http.StatusOK {
+		c.Writer.WriteHeader(writer.status)
+		c.Writer.Write(body)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	// Responses depend on the auth cookie - browsers can keep them but must revalidate
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("Vary", "Cookie")
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Writer.WriteHeader(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusOK)
+	c.Writer.Write(body)
+}
+
+// etagMatches compares If-None-Match against our tag using weak comparison
+// proxies such as nginx gzip turn strong tags into W/ ones so the prefix is ignored
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bufferedWriter collects the status and body instead of sending them
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+// WriteHeaderNow is a no-op, headers are sent once the body is tagged
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// AuditLog is one privileged action - written in the same transaction as the action itself
+// Rows are never changed or removed, the hooks below refuse it
+// No foreign key on the actor so their account can go without touching the log, the username is kept
+// as it was at the time and Actor is empty once the account is deleted
+type AuditLog struct {
+	ID            string `gorm:"type:uuid;primaryKey" json:"id"`
+	ActorID       string `gorm:"type:uuid;not null;index" json:"actorId"`
+	ActorUsername string `gorm:"type:varchar(30);not null;default:''" json:"actorUsername"`
+	Actor         User   `gorm:"foreignKey:ActorID;constraint:-" json:"actor,omitempty"`
+	Action        string `gorm:"type:varchar(50);not null;index" json:"action"` // e.g. "post.delete", "topic.update"
+	TargetType    string `gorm:"type:varchar(20);not null;index:idx_audit_target" json:"targetType"`
+	TargetID      string `gorm:"type:varchar(64);not null;index:idx_audit_target" json:"targetId"`
+
+	// JSON snapshots of the target, null when there is no before (create) or after (delete)
+	Before json.RawMessage `gorm:"type:jsonb" json:"before"`
+	After  json.RawMessage `gorm:"type:jsonb" json:"after"`
+
+	Reason    string    `gorm:"type:text;not null;default:''" json:"reason"`
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.New().String()
+	return
+}
+
+// https://gorm.io/docs/hooks.html - returning an error aborts the operation
+func (a *AuditLog) BeforeUpdate(tx *gorm.DB) (err error) {
+	return errors.New("audit log is append only")
+}
+
+func (a *AuditLog) BeforeDelete(tx *gorm.DB) (err error) {
+	return errors.New("audit log is append only")
+}
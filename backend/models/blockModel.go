@@ -0,0 +1,22 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// UserBlock is a user blocking another, the blocked users posts and comments are hidden from the blocker
+// and they can not reply to or mention the blocker
+type UserBlock struct {
+	ID        string    `gorm:"type:uuid;primaryKey" json:"id"`
+	BlockerID string    `gorm:"type:uuid;not null;index:unique_user_block,unique" json:"blockerId"`
+	BlockedID string    `gorm:"type:uuid;not null;index:unique_user_block,unique;index" json:"blockedId"`
+	Blocked   User      `gorm:"foreignKey:BlockedID" json:"blocked,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (b *UserBlock) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New().String()
+	return
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Bookmark is a post or comment a user saved for later
+// Polymorphic in the same way as Vote, a user can only save the same content once
+type Bookmark struct {
+	ID               string `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID           string `gorm:"type:uuid;not null;index:unique_bookmark,unique;index:idx_bookmarks_user_created,priority:1" json:"userId"`
+	BookmarkableID   string `gorm:"type:uuid;not null;index:unique_bookmark,unique;index" json:"bookmarkableId"`
+	BookmarkableType string `gorm:"type:varchar(20);not null;index:unique_bookmark,unique" json:"bookmarkableType"` // "post" or "comment"
+
+	// Optional ways to organise bookmarks, an empty folder is the default one
+	Folder string   `gorm:"type:varchar(100);not null;default:''" json:"folder"`
+	Tags   []string `gorm:"type:jsonb;serializer:json;not null;default:'[]'" json:"tags"`
+
+	CreatedAt time.Time `gorm:"index:idx_bookmarks_user_created,priority:2" json:"createdAt"`
+}
+
+func (b *Bookmark) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New().String()
+	return
+}
@@ -9,16 +9,33 @@ import (
 // Consider adding nesting later on
 type Comment struct {
 	ID       string `gorm:"type:uuid;primaryKey" json:"id"`
-	PostID   string `gorm:"type:uuid;not null" json:"postId"`
-	AuthorID string `gorm:"type:uuid;not null" json:"authorId"`
+	PostID   string `gorm:"type:uuid;not null;index:idx_comments_post_created,priority:1" json:"postId"`
+	AuthorID string `gorm:"type:uuid;not null;index:idx_comments_author_created,priority:1" json:"authorId"`
 
 	Post   Post `gorm:"foreignKey:PostID" json:"post,omitempty"`
 	Author User `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
 
 	Content string `gorm:"type:text;not null" json:"content"`
 
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	// Same as Post - Source is only kept for markdown
+	Format string  `gorm:"type:varchar(10);not null;default:'html'" json:"format"`
+	Source *string `gorm:"type:text" json:"source,omitempty"`
+
+	// Same as Post
+	LinkURL     *string      `gorm:"type:text" json:"-"`
+	LinkPreview *LinkPreview `gorm:"foreignKey:LinkURL;references:URL;constraint:-" json:"linkPreview,omitempty"`
+
+	// Tripped the content filter, only its author sees it until an admin approves it
+	IsHeld bool `gorm:"not null;default:false;index" json:"isHeld"`
+
+	// Denormalized vote counters - same as Post
+	LikeCount    int64 `gorm:"not null;default:0" json:"likeCount"`
+	DislikeCount int64 `gorm:"not null;default:0" json:"dislikeCount"`
+	Score        int64 `gorm:"not null;default:0" json:"score"` // likes - dislikes
+
+	CreatedAt time.Time  `gorm:"index:idx_comments_post_created,priority:2;index:idx_comments_author_created,priority:2" json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	EditedAt  *time.Time `json:"editedAt"` // null means never edited
 
 	// Deletes any related field with cascade
 	Votes []Vote `gorm:"foreignKey:VotableID;constraint:OnDelete:CASCADE" json:"votes,omitempty"`
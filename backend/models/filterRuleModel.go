@@ -0,0 +1,61 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// FilterRule is one admin managed rule of the content filter that new and edited posts and comments go through
+// word and regex rules match the text, the others are spam heuristics configured by Threshold
+type FilterRule struct {
+	ID        string `gorm:"type:uuid;primaryKey" json:"id"`
+	Kind      string `gorm:"type:varchar(20);not null" json:"kind"`
+	Pattern   string `gorm:"type:varchar(500);not null;default:''" json:"pattern"` // word or regex rules only
+	Threshold int    `gorm:"not null;default:0" json:"threshold"`                  // links, hours for repeat and new_account
+	Action    string `gorm:"type:varchar(10);not null" json:"action"`
+	IsEnabled bool   `gorm:"not null;default:true" json:"isEnabled"`
+
+	CreatedByID string `gorm:"type:uuid;not null" json:"createdById"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Filter rule kinds
+const (
+	FilterKindWord       = "word"        // whole word, any case
+	FilterKindRegex      = "regex"       // RE2 syntax, add (?i) to ignore case
+	FilterKindLinks      = "links"       // more than Threshold links
+	FilterKindRepeat     = "repeat"      // same content by the same author within Threshold hours
+	FilterKindNewAccount = "new_account" // links from an account younger than Threshold hours
+)
+
+// Filter actions - when several rules match reject wins over hold, and hold over mask
+const (
+	FilterActionReject = "reject"
+	FilterActionHold   = "hold" // saved but only shown once an admin approves it
+	FilterActionMask   = "mask" // matches are replaced with asterisks, word and regex rules only
+)
+
+// FilterHit records a rule matching a post or comment, for the hit statistics
+type FilterHit struct {
+	ID         string    `gorm:"type:uuid;primaryKey" json:"id"`
+	RuleID     string    `gorm:"type:uuid;not null;index:idx_filter_hits_rule_created,priority:1" json:"ruleId"`
+	UserID     string    `gorm:"type:uuid;not null;index" json:"userId"`
+	TargetType string    `gorm:"type:varchar(20);not null" json:"targetType"` // "post" or "comment"
+	Action     string    `gorm:"type:varchar(10);not null" json:"action"`
+	CreatedAt  time.Time `gorm:"index:idx_filter_hits_rule_created,priority:2" json:"createdAt"`
+}
+
+// https://gorm.io/docs/hooks.html
+// GORM hook that runs before creating FilterRule - generates a new unique id
+func (f *FilterRule) BeforeCreate(tx *gorm.DB) (err error) {
+	f.ID = uuid.New().String()
+	return
+}
+
+func (f *FilterHit) BeforeCreate(tx *gorm.DB) (err error) {
+	f.ID = uuid.New().String()
+	return
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// IdempotencyKey remembers the result of a request sent with an Idempotency-Key header
+// A retry with the same key gets the stored result back instead of being applied twice
+type IdempotencyKey struct {
+	ID        string    `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    string    `gorm:"type:uuid;not null;index:unique_idempotency_key,unique" json:"userId"`
+	Key       string    `gorm:"type:varchar(255);not null;index:unique_idempotency_key,unique" json:"key"`
+	Request   string    `gorm:"type:text;not null" json:"request"` // what the key was first used for
+	Response  string    `gorm:"type:text" json:"response"`         // JSON result of that request
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) (err error) {
+	k.ID = uuid.New().String()
+	return
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// LinkPreview is the OpenGraph metadata of a page linked from posts and comments, shared by everything linking it
+// Failed fetches are stored too, without a title, so a dead link is not fetched again until it goes stale
+type LinkPreview struct {
+	ID          string    `gorm:"type:uuid;primaryKey" json:"-"`
+	URL         string    `gorm:"type:text;not null;uniqueIndex" json:"url"`
+	Title       string    `gorm:"type:varchar(300);not null;default:''" json:"title"`
+	Description string    `gorm:"type:text;not null;default:''" json:"description"`
+	ImageURL    string    `gorm:"type:text;not null;default:''" json:"imageUrl,omitempty"`
+	SiteName    string    `gorm:"type:varchar(100);not null;default:''" json:"siteName,omitempty"`
+	FetchedAt   time.Time `gorm:"not null" json:"fetchedAt"`
+}
+
+func (l *LinkPreview) BeforeCreate(tx *gorm.DB) (err error) {
+	l.ID = uuid.New().String()
+	return
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Conversation is a private thread between two or more users
+// A one-to-one conversation has a DirectKey so each pair of users only ever has one, groups are always new
+type Conversation struct {
+	ID            string                    `gorm:"type:uuid;primaryKey" json:"id"`
+	IsGroup       bool                      `gorm:"not null;default:false" json:"isGroup"`
+	Title         string                    `gorm:"type:varchar(100);not null;default:''" json:"title"` // groups only
+	DirectKey     *string                   `gorm:"uniqueIndex" json:"-"`                               // both user ids, sorted
+	LastMessageAt time.Time                 `gorm:"not null;index" json:"lastMessageAt"`
+	Participants  []ConversationParticipant `gorm:"foreignKey:ConversationID;constraint:OnDelete:CASCADE" json:"participants,omitempty"`
+	CreatedAt     time.Time                 `json:"createdAt"`
+}
+
+func (c *Conversation) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New().String()
+	return
+}
+
+// ConversationParticipant is a user in a conversation and how far they have read it
+type ConversationParticipant struct {
+	ID             string     `gorm:"type:uuid;primaryKey" json:"-"`
+	ConversationID string     `gorm:"type:uuid;not null;index:unique_conversation_participant,unique" json:"conversationId"`
+	UserID         string     `gorm:"type:uuid;not null;index:unique_conversation_participant,unique;index" json:"userId"`
+	User           User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	LastReadAt     *time.Time `json:"lastReadAt"` // null means nothing read yet
+	CreatedAt      time.Time  `json:"joinedAt"`
+}
+
+func (p *ConversationParticipant) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.New().String()
+	return
+}
+
+// Message is one message in a conversation, content is sanitized HTML like comments
+type Message struct {
+	ID             string       `gorm:"type:uuid;primaryKey" json:"id"`
+	ConversationID string       `gorm:"type:uuid;not null;index:idx_messages_conversation_created,priority:1" json:"conversationId"`
+	Conversation   Conversation `gorm:"foreignKey:ConversationID;constraint:OnDelete:CASCADE" json:"-"`
+	SenderID       string       `gorm:"type:uuid;not null;index" json:"senderId"`
+	Sender         User         `gorm:"foreignKey:SenderID" json:"sender,omitempty"`
+	Content        string       `gorm:"type:text;not null" json:"content"`
+	CreatedAt      time.Time    `gorm:"index:idx_messages_conversation_created,priority:2" json:"createdAt"`
+}
+
+func (m *Message) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New().String()
+	return
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ModerationEvent records a moderator changing the pin / lock state of a post or archiving a topic
+// One row per change so the history of a thread can be shown
+type ModerationEvent struct {
+	ID         string `gorm:"type:uuid;primaryKey" json:"id"`
+	TargetID   string `gorm:"type:uuid;not null;index:idx_moderation_target" json:"targetId"`
+	TargetType string `gorm:"type:varchar(20);not null;index:idx_moderation_target" json:"targetType"` // "post" or "topic"
+	Action     string `gorm:"type:varchar(20);not null" json:"action"`                                 // pin, unpin, lock, unlock, archive, unarchive
+	Reason     string `gorm:"type:text;not null;default:''" json:"reason"`
+
+	ModeratorID string `gorm:"type:uuid;not null" json:"moderatorId"`
+	Moderator   User   `gorm:"foreignKey:ModeratorID" json:"moderator,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (m *ModerationEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New().String()
+	return
+}
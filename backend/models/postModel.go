@@ -10,25 +10,75 @@ import (
 // Read here for what Gorm Model provides
 type Post struct {
 	ID        string    `gorm:"type:uuid;primaryKey" json:"id"`
-	TopicID   string    `gorm:"type:uuid;not null" json:"topicId"`
-	AuthorID  string    `gorm:"type:uuid;not null" json:"authorId"`
+	TopicID   string    `gorm:"type:uuid;not null;index:idx_posts_topic_published,priority:1" json:"topicId"`
+	AuthorID  string    `gorm:"type:uuid;not null;index:idx_posts_author_published,priority:1" json:"authorId"`
 	Topic     Topic     `gorm:"foreignKey:TopicID" json:"topic,omitempty"`
 	Author    User      `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
 	Title     string    `gorm:"type:varchar(255);not null" json:"title"`
 	Content   string    `gorm:"type:text;not null" json:"content"`
 	IsPinned  bool      `gorm:"default:false" json:"isPinned"`
+	IsLocked  bool      `gorm:"default:false" json:"isLocked"` // no new comments or votes
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	ImageUrl  *string   `gorm:"type:text" json:"imageUrl,omitempty"`
 
+	// Drafts and scheduled posts are only visible to their author
+	// A scheduled post is published by the scheduler once PublishAt has passed
+	// Held posts tripped the content filter and wait for an admin to approve them
+	Status      string     `gorm:"type:varchar(20);not null;default:'published';index" json:"status"`
+	PublishAt   *time.Time `gorm:"index" json:"publishAt,omitempty"`
+	PublishedAt *time.Time `gorm:"index:idx_posts_topic_published,priority:2;index:idx_posts_author_published,priority:2;index" json:"publishedAt"` // feeds are ordered by this, not created_at
+
+	// Set on every edit of the title, content or image - null means never edited
+	// updated_at can not be used as pinning also touches it
+	EditedAt *time.Time `json:"editedAt"`
+
+	// html or markdown - Content is always the sanitized HTML that gets shown
+	// Markdown posts keep what was written in Source so it can be edited again
+	Format string  `gorm:"type:varchar(10);not null;default:'html'" json:"format"`
+	Source *string `gorm:"type:text" json:"source,omitempty"`
+
+	// First link in the content, LinkPreview is only loaded once it has been fetched
+	// No foreign key as the post is saved before the preview exists
+	LinkURL     *string      `gorm:"type:text" json:"-"`
+	LinkPreview *LinkPreview `gorm:"foreignKey:LinkURL;references:URL;constraint:-" json:"linkPreview,omitempty"`
+
+	// Denormalized vote counters - kept in sync by VoteService in the same transaction as the vote
+	// Saves joining and grouping the votes table on every feed query
+	LikeCount    int64 `gorm:"not null;default:0" json:"likeCount"`
+	DislikeCount int64 `gorm:"not null;default:0" json:"dislikeCount"`
+	Score        int64 `gorm:"not null;default:0;index" json:"score"` // likes - dislikes
+
 	// Deletes any related field with cascade
 	Comments []Comment `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"comments,omitempty"`
 	Votes    []Vote    `gorm:"foreignKey:VotableID;constraint:OnDelete:CASCADE" json:"votes,omitempty"`
 }
 
+// Post statuses
+const (
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+	PostStatusPublished = "published"
+	PostStatusHeld      = "held"
+)
+
+// Content formats of posts and comments
+const (
+	ContentFormatHTML     = "html"
+	ContentFormatMarkdown = "markdown"
+)
+
 // https://gorm.io/docs/hooks.html
 // GORM hook that runs before creating Topic - generates a new unique id
+// Posts created without a status are published straight away
 func (p *Post) BeforeCreate(tx *gorm.DB) (err error) {
 	p.ID = uuid.New().String()
+	if p.Status == "" {
+		p.Status = PostStatusPublished
+	}
+	if p.Status == PostStatusPublished && p.PublishedAt == nil {
+		now := time.Now()
+		p.PublishedAt = &now
+	}
 	return
 }
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Reaction is an emoji reaction of a user on a post or comment
+// Polymorphic in the same way as Vote but separate from it, reactions never change the score
+// A user can leave several different reactions on the same content but each emoji only once
+type Reaction struct {
+	ID            string    `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID        string    `gorm:"type:uuid;not null;index:unique_reaction,unique" json:"userId"`
+	ReactableID   string    `gorm:"type:uuid;not null;index:unique_reaction,unique;index" json:"reactableId"`
+	ReactableType string    `gorm:"type:varchar(20);not null;index:unique_reaction,unique" json:"reactableType"`
+	Emoji         string    `gorm:"type:varchar(32);not null;index:unique_reaction,unique" json:"emoji"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+func (r *Reaction) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New().String()
+	return
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ReactionType is one entry of the admin managed set of emoji users can react with
+// Inactive types stay on existing content but can not be added anymore
+type ReactionType struct {
+	ID        string    `gorm:"type:uuid;primaryKey" json:"id"`
+	Emoji     string    `gorm:"type:varchar(32);uniqueIndex;not null" json:"emoji"`
+	Name      string    `gorm:"type:varchar(32);uniqueIndex;not null" json:"name"` // shortcode e.g. "thumbs_up"
+	Position  int       `gorm:"not null;default:0" json:"position"`                // display order in the picker
+	IsActive  bool      `gorm:"not null;default:true" json:"isActive"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// https://gorm.io/docs/hooks.html
+// GORM hook that runs before creating ReactionType - generates a new unique id
+func (r *ReactionType) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New().String()
+	return
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ReadMarker is how far a user has read a thread
+// Comments created after LastReadAt are unread, PreviousReadAt is where the current visit started
+// so every request of one visit marks the same comments as new
+type ReadMarker struct {
+	ID             string     `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID         string     `gorm:"type:uuid;not null;index:unique_read_marker,unique" json:"userId"`
+	PostID         string     `gorm:"type:uuid;not null;index:unique_read_marker,unique;index" json:"postId"`
+	LastReadAt     time.Time  `gorm:"not null" json:"lastReadAt"`
+	PreviousReadAt *time.Time `json:"previousReadAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+func (r *ReadMarker) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New().String()
+	return
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Revision is a snapshot of a post or comment after an edit
+// Polymorphic like Vote - version 1 is the original, every edit or rollback adds the next version
+type Revision struct {
+	ID            string  `gorm:"type:uuid;primaryKey" json:"id"`
+	RevisableID   string  `gorm:"type:uuid;not null;index:unique_revision,unique" json:"revisableId"`
+	RevisableType string  `gorm:"type:varchar(20);not null;index:unique_revision,unique" json:"revisableType"`
+	Version       int     `gorm:"not null;index:unique_revision,unique" json:"version"`
+	Title         *string `gorm:"type:varchar(255)" json:"title,omitempty"` // comments have no title
+	Content       string  `gorm:"type:text;not null" json:"content"`
+	ImageUrl      *string `gorm:"type:text" json:"imageUrl,omitempty"`
+	Format        string  `gorm:"type:varchar(10);not null;default:'html'" json:"format"`
+	Source        *string `gorm:"type:text" json:"source,omitempty"` // markdown only
+
+	EditorID string `gorm:"type:uuid;not null" json:"editorId"`
+	Editor   User   `gorm:"foreignKey:EditorID" json:"editor,omitempty"`
+
+	// Set when the revision was made by rolling back, the version that was restored
+	RestoredFrom *int `json:"restoredFrom,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (r *Revision) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New().String()
+	return
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Topic subscription modes - following puts a topic in the home feed, muting keeps it out of every feed
+const (
+	SubscriptionFollow = "follow"
+	SubscriptionMute   = "mute"
+)
+
+// TopicSubscription is a user following or muting a topic, a topic is one or the other
+type TopicSubscription struct {
+	ID        string    `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    string    `gorm:"type:uuid;not null;index:unique_topic_subscription,unique" json:"userId"`
+	TopicID   string    `gorm:"type:uuid;not null;index:unique_topic_subscription,unique;index" json:"topicId"`
+	Topic     Topic     `gorm:"foreignKey:TopicID;constraint:OnDelete:CASCADE" json:"topic,omitempty"`
+	Mode      string    `gorm:"type:varchar(20);not null" json:"mode"` // follow or mute
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (s *TopicSubscription) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New().String()
+	return
+}
+
+// UserFollow is a user following another user, their posts show up in the followers home feed
+type UserFollow struct {
+	ID         string    `gorm:"type:uuid;primaryKey" json:"id"`
+	FollowerID string    `gorm:"type:uuid;not null;index:unique_user_follow,unique" json:"followerId"`
+	FolloweeID string    `gorm:"type:uuid;not null;index:unique_user_follow,unique;index" json:"followeeId"`
+	Followee   User      `gorm:"foreignKey:FolloweeID" json:"followee,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func (f *UserFollow) BeforeCreate(tx *gorm.DB) (err error) {
+	f.ID = uuid.New().String()
+	return
+}
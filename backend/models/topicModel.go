@@ -14,6 +14,21 @@ type Topic struct {
 	Slug      string    `gorm:"uniqueIndex;not null" json:"slug"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Read only - no new posts, and posts inside take no comments or votes
+	IsArchived bool `gorm:"default:false" json:"isArchived"`
+
+	// Shown on the topic page, Rules is Markdown source and rendered by the client
+	Description string `gorm:"type:varchar(500);not null;default:''" json:"description"`
+	Icon        string `gorm:"type:varchar(64);not null;default:''" json:"icon"`
+	Color       string `gorm:"type:varchar(7);not null;default:''" json:"color"` // #rrggbb
+	Rules       string `gorm:"type:text;not null;default:''" json:"rules"`
+
+	// Admin defined order, lowest first - ties fall back to newest first
+	Position int `gorm:"not null;default:0;index" json:"position"`
+
+	// Set when this topic sits under a category, only one level of nesting is allowed
+	ParentID *string `gorm:"type:uuid;index" json:"parentId"`
 }
 
 // https://gorm.io/docs/hooks.html
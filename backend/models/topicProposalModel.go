@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Topic proposal states - a proposal is pending until an admin approves or rejects it
+const (
+	ProposalStatusPending  = "pending"
+	ProposalStatusApproved = "approved"
+	ProposalStatusRejected = "rejected"
+)
+
+// TopicProposal is a user asking for a new topic, only admins create topics directly
+type TopicProposal struct {
+	ID          string `gorm:"type:uuid;primaryKey" json:"id"`
+	Name        string `gorm:"type:varchar(100);not null" json:"name"`
+	Description string `gorm:"type:varchar(500);not null;default:''" json:"description"`
+	Reason      string `gorm:"type:text;not null;default:''" json:"reason"` // why the proposer wants it
+	Status      string `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+
+	ProposerID string `gorm:"type:uuid;not null;index" json:"proposerId"`
+	Proposer   User   `gorm:"foreignKey:ProposerID" json:"proposer,omitempty"`
+
+	// Filled in once an admin has decided
+	ReviewerID *string    `gorm:"type:uuid" json:"reviewerId"`
+	Reviewer   *User      `gorm:"foreignKey:ReviewerID" json:"reviewer,omitempty"`
+	ReviewNote string     `gorm:"type:text;not null;default:''" json:"reviewNote"`
+	ReviewedAt *time.Time `json:"reviewedAt"`
+	TopicID    *string    `gorm:"type:uuid" json:"topicId"` // the topic an approval created
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (p *TopicProposal) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.New().String()
+	return
+}
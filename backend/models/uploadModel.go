@@ -0,0 +1,21 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Upload records who was handed each S3 image name, only they may use it as an avatar or delete it
+// Images uploaded before uploads were recorded have no row and are never deleted on anyones behalf
+type Upload struct {
+	ID         string    `gorm:"type:uuid;primaryKey" json:"id"`
+	Name       string    `gorm:"type:varchar(32);not null;uniqueIndex" json:"name"` // the S3 key
+	UploaderID string    `gorm:"type:uuid;not null;index" json:"uploaderId"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func (u *Upload) BeforeCreate(tx *gorm.DB) (err error) {
+	u.ID = uuid.New().String()
+	return
+}
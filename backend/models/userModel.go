@@ -6,6 +6,22 @@ import (
 	"time"
 )
 
+// Account states an admin can put a user in
+// muted users can still read and vote, suspended and banned users can not sign in at all
+const (
+	UserStatusActive    = "active"
+	UserStatusMuted     = "muted"
+	UserStatusSuspended = "suspended"
+	UserStatusBanned    = "banned"
+)
+
+// Content kept after its author deleted their account is moved to this placeholder user
+// It is created banned when migrating so nobody can ever sign in as it
+const (
+	DeletedUserID       = "00000000-0000-0000-0000-000000000000"
+	DeletedUserUsername = "[deleted user]"
+)
+
 // https://gorm.io/docs/models.html
 // Read here for what Gorm Model provides
 type User struct {
@@ -15,6 +31,22 @@ type User struct {
 	IsAdmin   bool      `gorm:"default:false" json:"isAdmin"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Kept out of the JSON since users are embedded as authors everywhere
+	// StatusUntil is when a mute / suspension runs out, nil means it does not
+	Status       string     `gorm:"type:varchar(20);default:'active';not null;index" json:"-"`
+	StatusUntil  *time.Time `json:"-"`
+	StatusReason string     `json:"-"`
+
+	// Profile, the client shows the username when DisplayName is empty
+	DisplayName string `gorm:"type:varchar(50);not null;default:''" json:"displayName"`
+	Bio         string `gorm:"type:varchar(500);not null;default:''" json:"bio"`
+
+	// Privacy toggles, only the owner sees these through /user/settings
+	// HideComments keeps the comment history off the profile, HideStats does the same for the post / comment counts
+	HideComments      bool       `gorm:"not null;default:false" json:"-"`
+	HideStats         bool       `gorm:"not null;default:false" json:"-"`
+	UsernameChangedAt *time.Time `json:"-"`
 }
 
 // https://gorm.io/docs/hooks.html
@@ -23,3 +55,14 @@ func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	u.ID = uuid.New().String()
 	return
 }
+
+// EffectiveStatus is the status the user is in right now, a mute or suspension that ran out counts as active
+func (u *User) EffectiveStatus(now time.Time) string {
+	if u.Status == "" {
+		return UserStatusActive
+	}
+	if u.StatusUntil != nil && !now.Before(*u.StatusUntil) {
+		return UserStatusActive
+	}
+	return u.Status
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// UsernameHistory is one username change, kept so links to the old profile still resolve
+type UsernameHistory struct {
+	ID          string    `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID      string    `gorm:"type:uuid;not null;index" json:"userId"`
+	User        User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	OldUsername string    `gorm:"not null;index" json:"oldUsername"`
+	NewUsername string    `gorm:"not null" json:"newUsername"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func (h *UsernameHistory) BeforeCreate(tx *gorm.DB) (err error) {
+	h.ID = uuid.New().String()
+	return
+}
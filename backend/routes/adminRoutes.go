@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/Kk120306/cvwo-2026/backend/controllers"
+	"github.com/Kk120306/cvwo-2026/backend/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRoutes sets up the admin only routes
+func AdminRoutes(r *gin.Engine, cfg *config.Config) {
+
+	auditController := controllers.NewAuditController()
+	accountController := controllers.NewAccountController()
+	contentFilterController := controllers.NewContentFilterController()
+	auth := middleware.NewAuthMiddleware(cfg.JWT)
+
+	// Every route under /admin needs an admin
+	adminRouter := r.Group("/admin", auth.CheckAuth, middleware.CheckAdmin)
+	{
+		adminRouter.GET("/audit", auditController.GetAuditLog)
+		adminRouter.GET("/audit/export", auditController.ExportAuditLog)
+		adminRouter.GET("/users/:username/status", accountController.GetAccountStatus)
+		adminRouter.PUT("/users/:username/status", accountController.SetAccountStatus)
+
+		// Content filter rules and what they held back
+		adminRouter.GET("/filter/rules", contentFilterController.GetRules)
+		adminRouter.POST("/filter/rules", contentFilterController.CreateRule)
+		adminRouter.PUT("/filter/rules/:id", contentFilterController.UpdateRule)
+		adminRouter.DELETE("/filter/rules/:id", contentFilterController.DeleteRule)
+		adminRouter.GET("/held", contentFilterController.GetHeld)
+		adminRouter.POST("/held/posts/:id/approve", contentFilterController.ApprovePost)
+		adminRouter.POST("/held/comments/:id/approve", contentFilterController.ApproveComment)
+	}
+}
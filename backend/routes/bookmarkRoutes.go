@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/Kk120306/cvwo-2026/backend/controllers"
+	"github.com/Kk120306/cvwo-2026/backend/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// BookmarkRoutes sets up the bookmark routes
+func BookmarkRoutes(r *gin.Engine, cfg *config.Config) {
+
+	bookmarkController := controllers.NewBookmarkController()
+	auth := middleware.NewAuthMiddleware(cfg.JWT)
+
+	// Bookmarks are private so every route needs a user
+	bookmarkRouter := r.Group("/bookmarks", auth.CheckAuth)
+	{
+		bookmarkRouter.GET("/", bookmarkController.GetBookmarks)
+		bookmarkRouter.GET("/folders", bookmarkController.GetBookmarkFolders)
+		bookmarkRouter.POST("/", bookmarkController.SaveBookmark)
+		// type is either "post" or "comment" and id is the content Id
+		bookmarkRouter.DELETE("/:type/:id", bookmarkController.RemoveBookmark)
+	}
+}
@@ -1,21 +1,29 @@
 package routes
 
 import (
+	"github.com/Kk120306/cvwo-2026/backend/config"
 	"github.com/Kk120306/cvwo-2026/backend/controllers"
 	"github.com/Kk120306/cvwo-2026/backend/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 // CommentRoutes set up the comment routes
-func CommentRoutes(r *gin.Engine) {
+func CommentRoutes(r *gin.Engine, cfg *config.Config) {
 
 	commentController := controllers.NewCommentController()
+	revisionController := controllers.NewRevisionController()
+	auth := middleware.NewAuthMiddleware(cfg.JWT)
 
 	commentRouter := r.Group("/comments") // Groups them under /comments
 	{
-		commentRouter.GET("/post/:postId", middleware.OptionalAuth, commentController.GetCommentsByPost)
-		commentRouter.POST("/create/:postId", middleware.CheckAuth, commentController.CreateComment)
-		commentRouter.DELETE("/delete/:id", middleware.CheckAuth, commentController.DeleteComment)
-		commentRouter.PUT("/update/:id", middleware.CheckAuth, commentController.UpdateComment)
+		commentRouter.GET("/post/:postId", auth.OptionalAuth, commentController.GetCommentsByPost)
+		commentRouter.POST("/create/:postId", auth.CheckAuth, commentController.CreateComment)
+		commentRouter.DELETE("/delete/:id", auth.CheckAuth, commentController.DeleteComment)
+		commentRouter.PUT("/update/:id", auth.CheckAuth, commentController.UpdateComment)
+
+		// Edit history - only admin can roll back
+		commentRouter.GET("/revisions/:id", auth.OptionalAuth, revisionController.GetCommentRevisions)
+		commentRouter.GET("/revisions/:id/diff", auth.OptionalAuth, revisionController.DiffCommentRevisions)
+		commentRouter.POST("/revisions/:id/rollback/:version", auth.CheckAuth, middleware.CheckAdmin, revisionController.RollbackComment)
 	}
 }
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/Kk120306/cvwo-2026/backend/controllers"
+	"github.com/Kk120306/cvwo-2026/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthRoutes sets up the liveness and readiness probes
+func HealthRoutes(r *gin.Engine, healthService *services.HealthService) {
+
+	healthController := controllers.NewHealthController(healthService)
+
+	// Kept at the root so docker / nginx can probe them without any auth
+	r.GET("/healthz", healthController.Liveness)
+	r.GET("/readyz", healthController.Readiness)
+}
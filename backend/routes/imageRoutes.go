@@ -1,19 +1,21 @@
 package routes
 
 import (
+	"github.com/Kk120306/cvwo-2026/backend/config"
 	"github.com/Kk120306/cvwo-2026/backend/controllers"
 	"github.com/Kk120306/cvwo-2026/backend/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 // ImageRoutes sets up the post routes
-func ImageRoutes(r *gin.Engine) {
+func ImageRoutes(r *gin.Engine, cfg *config.Config) {
 
-	imageController := controllers.NewS3Controller()
+	imageController := controllers.NewS3Controller(cfg.AWS)
+	auth := middleware.NewAuthMiddleware(cfg.JWT)
 
 	imageRouter := r.Group("/images") // Groups them under /images
 	{
-		imageRouter.GET("/s3Url", middleware.CheckAuth, imageController.GetS3UploadURL)
-		imageRouter.DELETE("/delete/:imageName", middleware.CheckAuth, imageController.DeleteS3Image)
+		imageRouter.GET("/s3Url", auth.CheckAuth, imageController.GetS3UploadURL)
+		imageRouter.DELETE("/delete/:imageName", auth.CheckAuth, imageController.DeleteS3Image)
 	}
 }
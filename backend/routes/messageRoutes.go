@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/Kk120306/cvwo-2026/backend/controllers"
+	"github.com/Kk120306/cvwo-2026/backend/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// MessageRoutes sets up the direct message routes
+func MessageRoutes(r *gin.Engine, cfg *config.Config) {
+
+	messageController := controllers.NewMessageController()
+	auth := middleware.NewAuthMiddleware(cfg.JWT)
+
+	// Messages are private so every route needs a user
+	messageRouter := r.Group("/messages", auth.CheckAuth)
+	{
+		messageRouter.GET("/conversations", messageController.GetConversations)
+		messageRouter.POST("/conversations", messageController.StartConversation)
+		messageRouter.GET("/conversations/:id/messages", messageController.GetMessages)
+		messageRouter.POST("/conversations/:id/messages", messageController.SendMessage)
+		messageRouter.POST("/conversations/:id/read", messageController.MarkRead)
+		messageRouter.GET("/unread", messageController.GetUnreadCount)
+		// server-sent events, see MessageController.Stream
+		messageRouter.GET("/stream", messageController.Stream)
+	}
+}
@@ -1,24 +1,40 @@
 package routes
 
 import (
+	"github.com/Kk120306/cvwo-2026/backend/config"
 	"github.com/Kk120306/cvwo-2026/backend/controllers"
 	"github.com/Kk120306/cvwo-2026/backend/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 // PostRoutes sets up the post routes
-func PostsRoutes(r *gin.Engine) {
+func PostsRoutes(r *gin.Engine, cfg *config.Config) {
 
 	postController := controllers.NewPostController()
+	revisionController := controllers.NewRevisionController()
+	moderationController := controllers.NewModerationController()
+	auth := middleware.NewAuthMiddleware(cfg.JWT)
 
 	postsRouter := r.Group("/posts") // Groups them under /posts
 	{
-		postsRouter.GET("/all", middleware.OptionalAuth, postController.GetAllPosts)
-		postsRouter.GET("/topic/:slug", middleware.OptionalAuth, postController.GetPostsByTopic)
-		postsRouter.GET("/id/:id", middleware.OptionalAuth, postController.GetPost)
-		postsRouter.POST("/create/:slug", middleware.CheckAuth, postController.CreatePost)
-		postsRouter.DELETE("/delete/:id", middleware.CheckAuth, postController.DeletePost)
-		postsRouter.PUT("/update/:id", middleware.CheckAuth, postController.UpdatePost)
-		postsRouter.PATCH("/pin/:id", middleware.CheckAuth, middleware.CheckAdmin, postController.TogglePinPost)
+		// ETag lets clients skip downloading a feed that has not changed
+		postsRouter.GET("/all", middleware.ETag, auth.OptionalAuth, postController.GetAllPosts)
+		postsRouter.GET("/topic/:slug", middleware.ETag, auth.OptionalAuth, postController.GetPostsByTopic)
+		postsRouter.GET("/home", auth.CheckAuth, postController.GetHomeFeed) // followed topics and users
+		postsRouter.GET("/id/:id", auth.OptionalAuth, postController.GetPost)
+		postsRouter.GET("/drafts", auth.CheckAuth, postController.GetDrafts) // callers own drafts and scheduled posts
+		postsRouter.POST("/create/:slug", auth.CheckAuth, postController.CreatePost)
+		postsRouter.DELETE("/delete/:id", auth.CheckAuth, postController.DeletePost)
+		postsRouter.PUT("/update/:id", auth.CheckAuth, postController.UpdatePost)
+
+		// Moderation - only admin can pin and lock, each change is recorded with who and why
+		postsRouter.PATCH("/pin/:id", auth.CheckAuth, middleware.CheckAdmin, moderationController.TogglePinPost)
+		postsRouter.PATCH("/moderate/:id", auth.CheckAuth, middleware.CheckAdmin, moderationController.ModeratePost)
+		postsRouter.GET("/moderation/:id", auth.CheckAuth, middleware.CheckAdmin, moderationController.GetPostModerationHistory)
+
+		// Edit history - only admin can roll back
+		postsRouter.GET("/revisions/:id", auth.OptionalAuth, revisionController.GetPostRevisions)
+		postsRouter.GET("/revisions/:id/diff", auth.OptionalAuth, revisionController.DiffPostRevisions)
+		postsRouter.POST("/revisions/:id/rollback/:version", auth.CheckAuth, middleware.CheckAdmin, revisionController.RollbackPost)
 	}
 }
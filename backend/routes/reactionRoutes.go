@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/Kk120306/cvwo-2026/backend/controllers"
+	"github.com/Kk120306/cvwo-2026/backend/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// ReactionRoutes sets up the emoji reaction routes
+func ReactionRoutes(r *gin.Engine, cfg *config.Config) {
+
+	reactionController := controllers.NewReactionController()
+	auth := middleware.NewAuthMiddleware(cfg.JWT)
+
+	reactionRouter := r.Group("/reactions") // Groups them under /reactions
+	{
+		reactionRouter.POST("/", auth.CheckAuth, reactionController.ToggleReaction)
+		reactionRouter.GET("/types", middleware.ETag, reactionController.GetReactionTypes)
+		// type is either "post" or "comment" and id is the content Id
+		reactionRouter.GET("/:type/:id", auth.OptionalAuth, middleware.ETag, reactionController.GetReactions)
+
+		// Only admin can manage the allowed reactions
+		reactionRouter.GET("/types/all", auth.CheckAuth, middleware.CheckAdmin, reactionController.GetAllReactionTypes)
+		reactionRouter.POST("/types", auth.CheckAuth, middleware.CheckAdmin, reactionController.CreateReactionType)
+		reactionRouter.PUT("/types/:id", auth.CheckAuth, middleware.CheckAdmin, reactionController.UpdateReactionType)
+		reactionRouter.DELETE("/types/:id", auth.CheckAuth, middleware.CheckAdmin, reactionController.DeleteReactionType)
+	}
+}
@@ -1,22 +1,40 @@
 package routes
 
 import (
+	"github.com/Kk120306/cvwo-2026/backend/config"
 	"github.com/Kk120306/cvwo-2026/backend/controllers"
 	"github.com/Kk120306/cvwo-2026/backend/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 // TopicRoutes sets up the topic routes
-func TopicRoutes(r *gin.Engine) {
+func TopicRoutes(r *gin.Engine, cfg *config.Config) {
 
 	topicController := controllers.NewTopicController()
+	proposalController := controllers.NewTopicProposalController()
+	moderationController := controllers.NewModerationController()
+	subscriptionController := controllers.NewSubscriptionController()
+	readController := controllers.NewReadController()
+	auth := middleware.NewAuthMiddleware(cfg.JWT)
 
 	topicRouter := r.Group("/topics") // Groups them under /auth
 	{
-		topicRouter.GET("/", topicController.GetTopics)
-		topicRouter.POST("/create", topicController.CreateTopic)
-		// Only admin can update, delete and update topics
-		topicRouter.DELETE("/delete/:slug", middleware.CheckAuth, middleware.CheckAdmin, topicController.DeleteTopic)
-		topicRouter.PUT("/update/:slug", middleware.CheckAuth, middleware.CheckAdmin, topicController.UpdateTopic)
+		topicRouter.GET("/", middleware.ETag, topicController.GetTopics)
+		// Per user - follow or mute a topic and mark all of its threads read
+		topicRouter.PUT("/subscription/:slug", auth.CheckAuth, subscriptionController.SetTopicSubscription)
+		topicRouter.POST("/read/:slug", auth.CheckAuth, readController.MarkTopicRead)
+		// Only admin can create, update and delete topics
+		topicRouter.POST("/create", auth.CheckAuth, middleware.CheckAdmin, topicController.CreateTopic)
+		topicRouter.DELETE("/delete/:slug", auth.CheckAuth, middleware.CheckAdmin, topicController.DeleteTopic)
+		topicRouter.PUT("/update/:slug", auth.CheckAuth, middleware.CheckAdmin, topicController.UpdateTopic)
+		topicRouter.PUT("/order", auth.CheckAuth, middleware.CheckAdmin, topicController.ReorderTopics)
+		topicRouter.PATCH("/archive/:slug", auth.CheckAuth, middleware.CheckAdmin, moderationController.ArchiveTopic)
+
+		// Everyone else proposes topics for an admin to approve
+		topicRouter.POST("/proposals", auth.CheckAuth, proposalController.CreateProposal)
+		topicRouter.GET("/proposals/mine", auth.CheckAuth, proposalController.GetMyProposals)
+		topicRouter.GET("/proposals", auth.CheckAuth, middleware.CheckAdmin, proposalController.GetProposals)
+		topicRouter.POST("/proposals/:id/approve", auth.CheckAuth, middleware.CheckAdmin, proposalController.ApproveProposal)
+		topicRouter.POST("/proposals/:id/reject", auth.CheckAuth, middleware.CheckAdmin, proposalController.RejectProposal)
 	}
 }
@@ -1,17 +1,43 @@
 package routes
 
 import (
+	"github.com/Kk120306/cvwo-2026/backend/config"
 	"github.com/Kk120306/cvwo-2026/backend/controllers"
+	"github.com/Kk120306/cvwo-2026/backend/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 // UserRoutes sets up the user routes
-func UserRoutes(r *gin.Engine) {
+func UserRoutes(r *gin.Engine, cfg *config.Config) {
 
-	userController := controllers.NewUserController()
+	userController := controllers.NewUserController(cfg.AWS)
+	subscriptionController := controllers.NewSubscriptionController()
+	accountDataController := controllers.NewAccountDataController(cfg.AWS)
+	blockController := controllers.NewBlockController()
+	auth := middleware.NewAuthMiddleware(cfg.JWT)
 
 	userRouter := r.Group("/user") // Groups them under /user
 	{
-		userRouter.GET("/profile/:username", userController.GetUserProfile)
+		userRouter.GET("/profile/:username", auth.OptionalAuth, userController.GetUserProfile)
+		userRouter.GET("/:username/posts", auth.OptionalAuth, userController.GetUserPosts)
+		userRouter.GET("/:username/comments", auth.OptionalAuth, userController.GetUserComments)
+
+		// Profile and privacy settings of the logged in user
+		userRouter.GET("/settings", auth.CheckAuth, userController.GetSettings)
+		userRouter.PUT("/settings", auth.CheckAuth, userController.UpdateSettings)
+
+		// Export and deletion of the logged in users data
+		userRouter.GET("/export", auth.CheckAuth, accountDataController.ExportData)
+		userRouter.DELETE("/account", auth.CheckAuth, accountDataController.DeleteAccount)
+
+		// Following - followed users and topics make up the home feed
+		userRouter.GET("/subscriptions", auth.CheckAuth, subscriptionController.GetSubscriptions)
+		userRouter.POST("/follow/:username", auth.CheckAuth, subscriptionController.FollowUser)
+		userRouter.DELETE("/follow/:username", auth.CheckAuth, subscriptionController.UnfollowUser)
+
+		// Blocking - blocked users are hidden from the blocker and can not reply to or mention them
+		userRouter.GET("/blocks", auth.CheckAuth, blockController.GetBlockedUsers)
+		userRouter.POST("/block/:username", auth.CheckAuth, blockController.BlockUser)
+		userRouter.DELETE("/block/:username", auth.CheckAuth, blockController.UnblockUser)
 	}
 }
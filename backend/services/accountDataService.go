@@ -0,0 +1,450 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/Kk120306/cvwo-2026/backend/cache"
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/helpers"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AccountDataService handles users exporting and deleting their own data
+type AccountDataService struct {
+	awsConfig config.AWSConfig
+}
+
+// NewAccountDataService creates a new instance of AccountDataService
+func NewAccountDataService(awsConfig config.AWSConfig) *AccountDataService {
+	return &AccountDataService{
+		awsConfig: awsConfig,
+	}
+}
+
+// Account deletion modes - anonymize keeps published posts and comments under the deleted user placeholder,
+// delete removes them along with everything under them
+const (
+	DeleteModeAnonymize = "anonymize"
+	DeleteModeDelete    = "delete"
+)
+
+// UserExport is everything stored about a user
+type UserExport struct {
+	ExportedAt time.Time        `json:"exportedAt"`
+	Profile    ExportProfile    `json:"profile"`
+	Posts      []models.Post    `json:"posts"`
+	Comments   []models.Comment `json:"comments"`
+	Votes      []models.Vote    `json:"votes"`
+	Images     []ExportImage    `json:"images"`
+}
+
+// ExportProfile is the account part of an export, including what is normally kept out of the JSON
+type ExportProfile struct {
+	ID              string                   `json:"id"`
+	Username        string                   `json:"username"`
+	DisplayName     string                   `json:"displayName"`
+	Bio             string                   `json:"bio"`
+	AvatarURL       string                   `json:"avatarUrl"`
+	IsAdmin         bool                     `json:"isAdmin"`
+	HideComments    bool                     `json:"hideComments"`
+	HideStats       bool                     `json:"hideStats"`
+	CreatedAt       time.Time                `json:"createdAt"`
+	UsernameHistory []models.UsernameHistory `json:"usernameHistory"`
+}
+
+// ExportImage is an image the user uploaded, Included says whether the archive has a copy under images/
+type ExportImage struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Included bool   `json:"included"`
+}
+
+// DeleteAccountInput represents a user deleting their account
+// Username has to match the account so it can not be deleted by accident
+type DeleteAccountInput struct {
+	Mode     string
+	Username string
+}
+
+// ExportUserData collects everything stored about the user, drafts included
+func (s *AccountDataService) ExportUserData(userID string) (*UserExport, error) {
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, errors.New("failed to export data")
+	}
+
+	export := UserExport{
+		ExportedAt: time.Now(),
+		Profile: ExportProfile{
+			ID:              user.ID,
+			Username:        user.Username,
+			DisplayName:     user.DisplayName,
+			Bio:             user.Bio,
+			AvatarURL:       user.AvatarURL,
+			IsAdmin:         user.IsAdmin,
+			HideComments:    user.HideComments,
+			HideStats:       user.HideStats,
+			CreatedAt:       user.CreatedAt,
+			UsernameHistory: []models.UsernameHistory{},
+		},
+		Posts:    []models.Post{},
+		Comments: []models.Comment{},
+		Votes:    []models.Vote{},
+		Images:   []ExportImage{},
+	}
+
+	err := database.DB.Where("user_id = ?", userID).Order("created_at ASC").
+		Find(&export.Profile.UsernameHistory).Error
+	if err != nil {
+		return nil, errors.New("failed to export data")
+	}
+
+	err = database.DB.Preload("Topic").Where("author_id = ?", userID).Order("created_at ASC").
+		Find(&export.Posts).Error
+	if err != nil {
+		return nil, errors.New("failed to export data")
+	}
+
+	err = database.DB.Where("author_id = ?", userID).Order("created_at ASC").
+		Find(&export.Comments).Error
+	if err != nil {
+		return nil, errors.New("failed to export data")
+	}
+
+	err = database.DB.Where("user_id = ?", userID).Order("created_at ASC").
+		Find(&export.Votes).Error
+	if err != nil {
+		return nil, errors.New("failed to export data")
+	}
+
+	for _, name := range s.uploadedImages(&user, export.Posts, export.Comments) {
+		export.Images = append(export.Images, ExportImage{Name: name, URL: helpers.ImageURL(s.awsConfig, name)})
+	}
+
+	return &export, nil
+}
+
+// WriteExportArchive writes the export as a ZIP - one JSON file per section and the images copied out of S3
+// An image that can not be read is left out and marked as not included in images.json
+func (s *AccountDataService) WriteExportArchive(w io.Writer, export *UserExport) error {
+	zw := zip.NewWriter(w)
+
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"posts.json", export.Posts},
+		{"comments.json", export.Comments},
+		{"votes.json", export.Votes},
+	}
+	for _, section := range sections {
+		if err := writeZipJSON(zw, section.name, section.data); err != nil {
+			return err
+		}
+	}
+
+	for i := range export.Images {
+		included, err := s.copyImage(zw, export.Images[i].Name)
+		if err != nil {
+			return err
+		}
+		export.Images[i].Included = included
+	}
+
+	// Written last so it can say which images made it in
+	if err := writeZipJSON(zw, "images.json", export.Images); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// copyImage copies one image into the archive, only errors writing the archive are returned
+func (s *AccountDataService) copyImage(zw *zip.Writer, imageName string) (bool, error) {
+	body, err := helpers.OpenImage(s.awsConfig, imageName)
+	if err != nil {
+		log.Printf("export: failed to read image %s: %v", imageName, err)
+		return false, nil
+	}
+	defer body.Close()
+
+	f, err := zw.Create("images/" + imageName)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func writeZipJSON(zw *zip.Writer, name string, data interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+// DeleteAccount deletes the users account
+// Votes, reactions, bookmarks, follows and the like always go, drafts and scheduled posts too
+// What happens to published posts and comments depends on the mode
+// Images that are no longer used are removed from S3 once the transaction has committed
+func (s *AccountDataService) DeleteAccount(userID string, input DeleteAccountInput) error {
+	if input.Mode != DeleteModeAnonymize && input.Mode != DeleteModeDelete {
+		return errors.New("invalid deletion mode")
+	}
+
+	var user models.User
+	var staleKeys []string
+	var images []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("user not found")
+			}
+			return errors.New("failed to delete account")
+		}
+
+		if input.Username != user.Username {
+			return errors.New("username does not match")
+		}
+		// The audit log has to keep pointing at the admin who did something
+		if user.IsAdmin {
+			return errors.New("admin accounts can not be deleted")
+		}
+
+		// Votes first, the counters of whatever they were on get recounted
+		keys, err := deleteUserVotes(tx, userID)
+		if err != nil {
+			return err
+		}
+		staleKeys = append(staleKeys, keys...)
+
+		// Everything that only matters to the user
+		personal := []interface{}{
+			&models.Reaction{}, &models.Bookmark{}, &models.ReadMarker{},
+			&models.TopicSubscription{}, &models.IdempotencyKey{}, &models.UsernameHistory{},
+		}
+		for _, model := range personal {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return errors.New("failed to delete account")
+			}
+		}
+		err = tx.Where("follower_id = ? OR followee_id = ?", userID, userID).Delete(&models.UserFollow{}).Error
+		if err != nil {
+			return errors.New("failed to delete account")
+		}
+		err = tx.Where("blocker_id = ? OR blocked_id = ?", userID, userID).Delete(&models.UserBlock{}).Error
+		if err != nil {
+			return errors.New("failed to delete account")
+		}
+
+		// Unpublished posts never get published under the placeholder, in delete mode nothing is kept
+		postQuery := tx.Model(&models.Post{}).Where("author_id = ?", userID)
+		if input.Mode == DeleteModeAnonymize {
+			postQuery = postQuery.Where("status <> ?", models.PostStatusPublished)
+		}
+		var posts []models.Post
+		if err := postQuery.Select("id", "content", "image_url").Find(&posts).Error; err != nil {
+			return errors.New("failed to delete account")
+		}
+
+		var comments []models.Comment
+		if input.Mode == DeleteModeDelete {
+			if err := tx.Select("id", "content").Where("author_id = ?", userID).Find(&comments).Error; err != nil {
+				return errors.New("failed to delete account")
+			}
+		}
+
+		// Only the users own uploads are deleted, content can embed anyones image by its URL
+		if referenced := s.uploadedImages(&user, posts, comments); len(referenced) > 0 {
+			err := tx.Model(&models.Upload{}).
+				Where("uploader_id = ? AND name IN ?", userID, referenced).
+				Pluck("name", &images).Error
+			if err != nil {
+				return errors.New("failed to delete account")
+			}
+		}
+		if len(images) > 0 {
+			if err := tx.Where("name IN ?", images).Delete(&models.Upload{}).Error; err != nil {
+				return errors.New("failed to delete account")
+			}
+		}
+
+		postIDs := make([]string, 0, len(posts))
+		for _, post := range posts {
+			postIDs = append(postIDs, post.ID)
+			staleKeys = append(staleKeys, cache.VoteCountKey("post", post.ID))
+		}
+		commentIDs, err := deletePostsTx(tx, postIDs)
+		if err != nil {
+			return errors.New("failed to delete account")
+		}
+
+		// Comments under the users own posts went with them above
+		remaining := make([]string, 0, len(comments))
+		for _, comment := range comments {
+			remaining = append(remaining, comment.ID)
+		}
+		if err := deleteCommentsTx(tx, remaining); err != nil {
+			return errors.New("failed to delete account")
+		}
+		for _, commentID := range append(commentIDs, remaining...) {
+			staleKeys = append(staleKeys, cache.VoteCountKey("comment", commentID))
+		}
+
+		// Whatever is left is kept, just no longer tied to the user
+		reassign := []struct {
+			model  interface{}
+			column string
+		}{
+			{&models.Post{}, "author_id"},
+			{&models.Comment{}, "author_id"},
+			{&models.Revision{}, "editor_id"},
+			{&models.ModerationEvent{}, "moderator_id"},
+			{&models.TopicProposal{}, "reviewer_id"},
+			{&models.FilterRule{}, "created_by_id"},
+			{&models.FilterHit{}, "user_id"},  // the hit statistics stay right
+			{&models.Upload{}, "uploader_id"}, // images still shown in kept content, nobody else can claim them
+		}
+		for _, r := range reassign {
+			err := tx.Model(r.model).Where(r.column+" = ?", userID).
+				UpdateColumn(r.column, models.DeletedUserID).Error
+			if err != nil {
+				return errors.New("failed to delete account")
+			}
+		}
+
+		// Pending proposals are withdrawn, reviewed ones stay as a record of the decision
+		err = tx.Where("proposer_id = ? AND status = ?", userID, models.ProposalStatusPending).
+			Delete(&models.TopicProposal{}).Error
+		if err != nil {
+			return errors.New("failed to delete account")
+		}
+		err = tx.Model(&models.TopicProposal{}).Where("proposer_id = ?", userID).
+			UpdateColumn("proposer_id", models.DeletedUserID).Error
+		if err != nil {
+			return errors.New("failed to delete account")
+		}
+
+		// The other side keeps their copy of a conversation unless everything is deleted
+		if input.Mode == DeleteModeDelete {
+			err = tx.Where("sender_id = ?", userID).Delete(&models.Message{}).Error
+		} else {
+			err = tx.Model(&models.Message{}).Where("sender_id = ?", userID).
+				UpdateColumn("sender_id", models.DeletedUserID).Error
+		}
+		if err != nil {
+			return errors.New("failed to delete account")
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.ConversationParticipant{}).Error; err != nil {
+			return errors.New("failed to delete account")
+		}
+		// nobody can read a conversation once its last participant is gone
+		err = tx.Where("NOT EXISTS (SELECT 1 FROM conversation_participants WHERE conversation_participants.conversation_id = conversations.id)").
+			Delete(&models.Conversation{}).Error
+		if err != nil {
+			return errors.New("failed to delete account")
+		}
+
+		if err := tx.Delete(&user).Error; err != nil {
+			return errors.New("failed to delete account")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cache.Invalidate(staleKeys...)
+	cache.InvalidatePrefix(cache.FeedPrefix)
+
+	// Nothing points at these anymore, cleaning them up is best effort
+	for _, imageName := range images {
+		if err := helpers.DeleteImage(s.awsConfig, imageName); err != nil {
+			log.Printf("failed to delete image %s of deleted account: %v", imageName, err)
+		}
+	}
+
+	return nil
+}
+
+// deleteUserVotes removes every vote of the user and recounts what they were on
+// Returns the cache keys of the counts that changed
+func deleteUserVotes(tx *gorm.DB, userID string) ([]string, error) {
+	var votes []models.Vote
+	if err := tx.Select("votable_id", "votable_type").Where("user_id = ?", userID).Find(&votes).Error; err != nil {
+		return nil, errors.New("failed to delete account")
+	}
+	if len(votes) == 0 {
+		return nil, nil
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.Vote{}).Error; err != nil {
+		return nil, errors.New("failed to delete account")
+	}
+
+	idsByType := map[string][]string{}
+	var keys []string
+	for _, vote := range votes {
+		idsByType[vote.VotableType] = append(idsByType[vote.VotableType], vote.VotableID)
+		keys = append(keys, cache.VoteCountKey(vote.VotableType, vote.VotableID))
+	}
+	for votableType, ids := range idsByType {
+		if err := database.RecountVoteCounters(tx, votableType, ids); err != nil {
+			return nil, errors.New("failed to delete account")
+		}
+	}
+
+	return keys, nil
+}
+
+// uploadedImages lists the S3 images behind the avatar and in the given posts and comments, without repeats
+func (s *AccountDataService) uploadedImages(user *models.User, posts []models.Post, comments []models.Comment) []string {
+	seen := map[string]bool{}
+	var names []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	if name, ok := helpers.UploadedImageName(s.awsConfig, user.AvatarURL); ok {
+		add(name)
+	}
+	for _, post := range posts {
+		if post.ImageUrl != nil {
+			if name, ok := helpers.UploadedImageName(s.awsConfig, *post.ImageUrl); ok {
+				add(name)
+			}
+		}
+		for _, name := range helpers.UploadedImageNames(s.awsConfig, post.Content) {
+			add(name)
+		}
+	}
+	for _, comment := range comments {
+		for _, name := range helpers.UploadedImageNames(s.awsConfig, comment.Content) {
+			add(name)
+		}
+	}
+
+	return names
+}
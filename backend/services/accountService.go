@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AccountService handles muting, suspending and banning users
+type AccountService struct{}
+
+// NewAccountService creates a new instance of AccountService
+func NewAccountService() *AccountService {
+	return &AccountService{}
+}
+
+// AccountStatus is what admins see about the state of an account
+type AccountStatus struct {
+	UserID   string     `json:"userId"`
+	Username string     `json:"username"`
+	Status   string     `json:"status"`
+	Until    *time.Time `json:"until"`
+	Reason   string     `json:"reason"`
+	// Status can still say muted / suspended after it ran out, Effective is what is enforced
+	Effective string `json:"effective"`
+}
+
+// SetAccountStatusInput represents an admin putting an account in a new state
+// Until is required for suspensions, optional for mutes and not allowed for bans or active
+type SetAccountStatusInput struct {
+	Status  string
+	Until   *time.Time
+	Reason  string
+	ActorID string
+}
+
+// audit actions per status
+var accountStatusActions = map[string]string{
+	models.UserStatusActive:    "user.restore",
+	models.UserStatusMuted:     "user.mute",
+	models.UserStatusSuspended: "user.suspend",
+	models.UserStatusBanned:    "user.ban",
+}
+
+// GetAccountStatus returns the current state of a users account
+func (s *AccountService) GetAccountStatus(username string) (*AccountStatus, error) {
+	var user models.User
+	if err := database.DB.First(&user, "username = ?", username).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, errors.New("database error")
+	}
+
+	status := toAccountStatus(&user)
+	return &status, nil
+}
+
+// SetAccountStatus mutes, suspends, bans or restores a user and records it in the audit log
+// Admins can not restrict themselves or other admins
+func (s *AccountService) SetAccountStatus(username string, input SetAccountStatusInput) (*AccountStatus, error) {
+	action, ok := accountStatusActions[input.Status]
+	if !ok {
+		return nil, errors.New("invalid account status")
+	}
+
+	switch input.Status {
+	case models.UserStatusSuspended:
+		if input.Until == nil {
+			return nil, errors.New("suspensions need an until time")
+		}
+	case models.UserStatusActive, models.UserStatusBanned:
+		if input.Until != nil {
+			return nil, errors.New("until is only allowed for mutes and suspensions")
+		}
+	}
+	if input.Until != nil && !input.Until.After(time.Now()) {
+		return nil, errors.New("until must be in the future")
+	}
+
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// the deleted user placeholder has to stay banned
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&user, "username = ? AND id <> ?", username, models.DeletedUserID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("user not found")
+			}
+			return errors.New("database error")
+		}
+
+		if user.ID == input.ActorID {
+			return errors.New("you can not change your own account status")
+		}
+		if user.IsAdmin && input.Status != models.UserStatusActive {
+			return errors.New("admins can not be restricted")
+		}
+
+		before := userSnapshot(&user)
+
+		// Set explicitly so clearing Until and Reason is written too
+		user.Status = input.Status
+		user.StatusUntil = input.Until
+		user.StatusReason = strings.TrimSpace(input.Reason)
+		if input.Status == models.UserStatusActive {
+			user.StatusReason = ""
+		}
+
+		err = tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"status":        user.Status,
+			"status_until":  user.StatusUntil,
+			"status_reason": user.StatusReason,
+		}).Error
+		if err != nil {
+			return errors.New("failed to update account status")
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    input.ActorID,
+			Action:     action,
+			TargetType: "user",
+			TargetID:   user.ID,
+			Before:     before,
+			After:      userSnapshot(&user),
+			Reason:     input.Reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	status := toAccountStatus(&user)
+	return &status, nil
+}
+
+func toAccountStatus(user *models.User) AccountStatus {
+	return AccountStatus{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Status:    user.Status,
+		Until:     user.StatusUntil,
+		Reason:    user.StatusReason,
+		Effective: user.EffectiveStatus(time.Now()),
+	}
+}
+
+// ensureCanPost returns an error unless the user is allowed to write posts, comments and reactions
+// Checked inside the write transaction where there is one, so it also catches a mute that lands mid request
+func ensureCanPost(tx *gorm.DB, userID string) error {
+	status, err := accountStatus(tx, userID)
+	if err != nil {
+		return err
+	}
+	if status != models.UserStatusActive {
+		return errors.New("account is " + status)
+	}
+	return nil
+}
+
+// ensureCanInteract is ensureCanPost for things muted users are still allowed to do, like voting
+func ensureCanInteract(tx *gorm.DB, userID string) error {
+	status, err := accountStatus(tx, userID)
+	if err != nil {
+		return err
+	}
+	if status == models.UserStatusSuspended || status == models.UserStatusBanned {
+		return errors.New("account is " + status)
+	}
+	return nil
+}
+
+func accountStatus(tx *gorm.DB, userID string) (string, error) {
+	var user models.User
+	err := tx.Select("id", "status", "status_until").First(&user, "id = ?", userID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.New("user not found")
+		}
+		return "", errors.New("database error")
+	}
+	return user.EffectiveStatus(time.Now()), nil
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm"
+)
+
+// AuditService handles reading the audit log, entries are written with recordAudit
+type AuditService struct{}
+
+// NewAuditService creates a new instance of AuditService
+func NewAuditService() *AuditService {
+	return &AuditService{}
+}
+
+// AuditEntry is what a privileged action records about itself
+// Before and After are marshalled to JSON, leave them nil when there is nothing to snapshot
+type AuditEntry struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     interface{}
+	After      interface{}
+	Reason     string
+}
+
+// AuditFilter narrows down the audit log, empty fields match everything
+type AuditFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+}
+
+// AuditPage is one page of the audit log
+type AuditPage struct {
+	Entries []models.AuditLog `json:"entries"`
+	Total   int64             `json:"total"`
+	Page    int               `json:"page"`
+	Limit   int               `json:"limit"`
+}
+
+// the most rows a single export returns
+const maxAuditExport = 10000
+
+// recordAudit writes an audit entry inside the callers transaction
+// so the entry only exists if the action it describes was committed
+func recordAudit(tx *gorm.DB, entry AuditEntry) error {
+	before, err := auditSnapshot(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := auditSnapshot(entry.After)
+	if err != nil {
+		return err
+	}
+
+	// who did it stays readable after the account is deleted or renamed
+	var actorUsername string
+	err = tx.Model(&models.User{}).Select("username").Where("id = ?", entry.ActorID).Scan(&actorUsername).Error
+	if err != nil {
+		return errors.New("failed to write audit log")
+	}
+
+	auditLog := models.AuditLog{
+		ActorID:       entry.ActorID,
+		ActorUsername: actorUsername,
+		Action:        entry.Action,
+		TargetType:    entry.TargetType,
+		TargetID:      entry.TargetID,
+		Before:        before,
+		After:         after,
+		Reason:        strings.TrimSpace(entry.Reason),
+	}
+	if err := tx.Create(&auditLog).Error; err != nil {
+		return errors.New("failed to write audit log")
+	}
+
+	return nil
+}
+
+func auditSnapshot(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.New("failed to write audit log")
+	}
+	return data, nil
+}
+
+// Snapshots only keep the fields worth auditing, not loaded relations or counters
+
+func postSnapshot(post *models.Post) map[string]interface{} {
+	return map[string]interface{}{
+		"id":       post.ID,
+		"title":    post.Title,
+		"content":  post.Content,
+		"imageUrl": post.ImageUrl,
+		"authorId": post.AuthorID,
+		"topicId":  post.TopicID,
+		"status":   post.Status,
+		"isPinned": post.IsPinned,
+		"isLocked": post.IsLocked,
+	}
+}
+
+func commentSnapshot(comment *models.Comment) map[string]interface{} {
+	return map[string]interface{}{
+		"id":       comment.ID,
+		"content":  comment.Content,
+		"authorId": comment.AuthorID,
+		"postId":   comment.PostID,
+		"isHeld":   comment.IsHeld,
+	}
+}
+
+func topicSnapshot(topic *models.Topic) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          topic.ID,
+		"name":        topic.Name,
+		"slug":        topic.Slug,
+		"isArchived":  topic.IsArchived,
+		"description": topic.Description,
+		"icon":        topic.Icon,
+		"color":       topic.Color,
+		"rules":       topic.Rules,
+		"position":    topic.Position,
+		"parentId":    topic.ParentID,
+	}
+}
+
+func userSnapshot(user *models.User) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           user.ID,
+		"username":     user.Username,
+		"status":       user.Status,
+		"statusUntil":  user.StatusUntil,
+		"statusReason": user.StatusReason,
+	}
+}
+
+// GetAuditLog returns a page of the audit log, newest first
+func (s *AuditService) GetAuditLog(filter AuditFilter, page, limit int) (*AuditPage, error) {
+	result := AuditPage{Entries: []models.AuditLog{}, Page: page, Limit: limit}
+
+	err := s.filtered(filter).Model(&models.AuditLog{}).Count(&result.Total).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve audit log")
+	}
+
+	err = s.filtered(filter).
+		Preload("Actor").
+		Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&result.Entries).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve audit log")
+	}
+
+	return &result, nil
+}
+
+// ExportAuditLog returns every matching entry, newest first, up to maxAuditExport
+func (s *AuditService) ExportAuditLog(filter AuditFilter) ([]models.AuditLog, error) {
+	entries := []models.AuditLog{}
+	err := s.filtered(filter).
+		Preload("Actor").
+		Order("created_at DESC").
+		Limit(maxAuditExport).
+		Find(&entries).Error
+	if err != nil {
+		return nil, errors.New("failed to export audit log")
+	}
+
+	return entries, nil
+}
+
+// filtered builds the WHERE clause shared by listing and exporting
+func (s *AuditService) filtered(filter AuditFilter) *gorm.DB {
+	query := database.DB.Model(&models.AuditLog{})
+
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	return query
+}
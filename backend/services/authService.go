@@ -2,9 +2,9 @@ package services
 
 import (
 	"errors"
-	"os"
 	"time"
 
+	"github.com/Kk120306/cvwo-2026/backend/config"
 	"github.com/Kk120306/cvwo-2026/backend/database"
 	"github.com/Kk120306/cvwo-2026/backend/models"
 	"github.com/golang-jwt/jwt/v5"
@@ -12,11 +12,15 @@ import (
 )
 
 // AuthService handles authentication business logic
-type AuthService struct{}
+type AuthService struct {
+	jwtConfig config.JWTConfig
+}
 
 // NewAuthService creates a new instance of AuthService
-func NewAuthService() *AuthService {
-	return &AuthService{}
+func NewAuthService(jwtConfig config.JWTConfig) *AuthService {
+	return &AuthService{
+		jwtConfig: jwtConfig,
+	}
 }
 
 // AuthInput represents the data needed for signup
@@ -30,6 +34,12 @@ type UserResponse struct {
 	Username  string `json:"username"`
 	AvatarURL string `json:"avatarUrl"`
 	IsAdmin   bool   `json:"isAdmin"`
+
+	DisplayName string `json:"displayName"`
+
+	// Lets a muted user see why they can not post and until when
+	Status      string     `json:"status"`
+	StatusUntil *time.Time `json:"statusUntil"`
 }
 
 // CreateUser creates a new user in the database
@@ -92,7 +102,7 @@ func (s *AuthService) GenerateToken(userID string) (string, error) {
 	})
 
 	// Sign and get the complete encoded token as a string using the secret
-	tokenString, err := token.SignedString([]byte(os.Getenv("SECRET")))
+	tokenString, err := token.SignedString([]byte(s.jwtConfig.Secret))
 	if err != nil {
 		return "", errors.New("failed to create token")
 	}
@@ -108,7 +118,7 @@ func (s *AuthService) ValidateToken(tokenString string) (uint, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return []byte(os.Getenv("SECRET")), nil
+		return []byte(s.jwtConfig.Secret), nil
 	})
 
 	if err != nil {
@@ -128,12 +138,30 @@ func (s *AuthService) ValidateToken(tokenString string) (uint, error) {
 	return 0, errors.New("invalid token")
 }
 
+// CheckCanSignIn returns an error when the account is banned or suspended
+func (s *AuthService) CheckCanSignIn(user *models.User) error {
+	switch user.EffectiveStatus(time.Now()) {
+	case models.UserStatusBanned:
+		return errors.New("account is banned")
+	case models.UserStatusSuspended:
+		return errors.New("account is suspended")
+	}
+	return nil
+}
+
 // ToUserResponse converts a User model to a sanitized UserResponse
 func (s *AuthService) ToUserResponse(user *models.User) UserResponse {
-	return UserResponse{
+	response := UserResponse{
 		ID:        user.ID,
 		Username:  user.Username,
 		AvatarURL: user.AvatarURL,
 		IsAdmin:   user.IsAdmin,
+		Status:    user.EffectiveStatus(time.Now()),
+
+		DisplayName: user.DisplayName,
+	}
+	if response.Status != models.UserStatusActive {
+		response.StatusUntil = user.StatusUntil
 	}
+	return response
 }
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/helpers"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BlockService handles users blocking each other
+type BlockService struct{}
+
+// NewBlockService creates a new instance of BlockService
+func NewBlockService() *BlockService {
+	return &BlockService{}
+}
+
+// BlockUser makes blockerID block the user with the username, blocking twice is a no-op
+// Follows between the two are removed both ways
+func (s *BlockService) BlockUser(blockerID, username string) error {
+	blocked, err := findUserIDByUsername(username)
+	if err != nil {
+		return err
+	}
+	if blocked == blockerID {
+		return errors.New("you can not block yourself")
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		block := models.UserBlock{BlockerID: blockerID, BlockedID: blocked}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("(follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)",
+			blockerID, blocked, blocked, blockerID).
+			Delete(&models.UserFollow{}).Error
+	})
+	if err != nil {
+		return errors.New("failed to block user")
+	}
+
+	return nil
+}
+
+// UnblockUser removes a block, unblocking someone who is not blocked is a no-op
+func (s *BlockService) UnblockUser(blockerID, username string) error {
+	blocked, err := findUserIDByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	err = database.DB.Where("blocker_id = ? AND blocked_id = ?", blockerID, blocked).
+		Delete(&models.UserBlock{}).Error
+	if err != nil {
+		return errors.New("failed to unblock user")
+	}
+
+	return nil
+}
+
+// GetBlockedUsers lists who the user has blocked, most recent first
+func (s *BlockService) GetBlockedUsers(userID string) ([]models.UserBlock, error) {
+	blocks := []models.UserBlock{}
+	err := database.DB.Preload("Blocked").Where("blocker_id = ?", userID).Order("created_at DESC").Find(&blocks).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve blocked users")
+	}
+
+	return blocks, nil
+}
+
+// isBlockedBy reports whether blockerID has blocked userID
+func isBlockedBy(tx *gorm.DB, userID, blockerID string) (bool, error) {
+	var count int64
+	err := tx.Model(&models.UserBlock{}).
+		Where("blocker_id = ? AND blocked_id = ?", blockerID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, errors.New("database error")
+	}
+	return count > 0, nil
+}
+
+// ensureNotBlocked returns an error when authorID writes something a user who blocked them would get -
+// a reply to one of their posts (replyToID, empty when there is none) or an @mention in content
+func ensureNotBlocked(tx *gorm.DB, authorID, replyToID, content string) error {
+	if replyToID != "" && replyToID != authorID {
+		blocked, err := isBlockedBy(tx, authorID, replyToID)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return errors.New("you can not reply to this user")
+		}
+	}
+
+	mentions := helpers.ExtractMentions(content)
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	var count int64
+	err := tx.Model(&models.UserBlock{}).
+		Joins("JOIN users ON users.id = user_blocks.blocker_id").
+		Where("user_blocks.blocked_id = ? AND users.username IN ?", authorID, mentions).
+		Count(&count).Error
+	if err != nil {
+		return errors.New("database error")
+	}
+	if count > 0 {
+		return errors.New("you can not mention a user who blocked you")
+	}
+
+	return nil
+}
+
+// excludeBlockedAuthors leaves out rows of table written by users the viewer has blocked
+func excludeBlockedAuthors(query *gorm.DB, table, userID string) *gorm.DB {
+	return query.Where(
+		table+".author_id NOT IN (SELECT blocked_id FROM user_blocks WHERE blocker_id = ?)", userID)
+}
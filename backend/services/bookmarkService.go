@@ -0,0 +1,272 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/helpers"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm/clause"
+)
+
+// BookmarkService handles saving posts and comments for later
+type BookmarkService struct{}
+
+// NewBookmarkService creates a new instance of BookmarkService
+func NewBookmarkService() *BookmarkService {
+	return &BookmarkService{}
+}
+
+// BookmarkInput represents a post or comment being saved, saving it again moves it to the new folder / tags
+type BookmarkInput struct {
+	BookmarkableID   string
+	BookmarkableType string // "post" or "comment"
+	Folder           string
+	Tags             []string
+}
+
+// BookmarkFilter narrows down the bookmark list, empty fields match everything
+type BookmarkFilter struct {
+	Type   string
+	Folder *string // nil is every folder, "" is the default one
+	Tag    string
+}
+
+// BookmarkWithTarget is a bookmark with the post or comment it points at
+type BookmarkWithTarget struct {
+	models.Bookmark
+	Post    *models.Post    `json:"post,omitempty"`
+	Comment *models.Comment `json:"comment,omitempty"`
+}
+
+// BookmarkPage is one page of bookmarks, NextCursor is empty on the last page
+type BookmarkPage struct {
+	Bookmarks  []BookmarkWithTarget `json:"bookmarks"`
+	NextCursor string               `json:"nextCursor"`
+}
+
+// BookmarkFolder is a folder and how many bookmarks are in it
+type BookmarkFolder struct {
+	Folder string `json:"folder"`
+	Count  int64  `json:"count"`
+}
+
+// limits on how bookmarks are organised
+const (
+	maxBookmarkFolder = 100
+	maxBookmarkTags   = 10
+	maxBookmarkTag    = 32
+)
+
+// SaveBookmark bookmarks a post or comment for the user, or updates the folder and tags of an existing bookmark
+func (s *BookmarkService) SaveBookmark(userID string, input BookmarkInput) (*models.Bookmark, error) {
+	if input.BookmarkableType != "post" && input.BookmarkableType != "comment" {
+		return nil, errors.New("bookmarkableType must be 'post' or 'comment'")
+	}
+
+	folder := strings.TrimSpace(input.Folder)
+	if len(folder) > maxBookmarkFolder {
+		return nil, errors.New("folder name is too long")
+	}
+	tags, err := normalizeBookmarkTags(input.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only published content can be saved, the same that can be read
+	if err := bookmarkableExists(input.BookmarkableType, input.BookmarkableID); err != nil {
+		return nil, err
+	}
+
+	bookmark := models.Bookmark{
+		UserID:           userID,
+		BookmarkableID:   input.BookmarkableID,
+		BookmarkableType: input.BookmarkableType,
+		Folder:           folder,
+		Tags:             tags,
+	}
+
+	// Saving again keeps the original bookmark time so it does not jump to the top of the list
+	err = database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "bookmarkable_id"}, {Name: "bookmarkable_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"folder", "tags"}),
+	}).Create(&bookmark).Error
+	if err != nil {
+		return nil, errors.New("failed to save bookmark")
+	}
+
+	// On a conflict the id and created at of the existing row are not returned
+	err = database.DB.First(&bookmark, "user_id = ? AND bookmarkable_id = ? AND bookmarkable_type = ?",
+		userID, input.BookmarkableID, input.BookmarkableType).Error
+	if err != nil {
+		return nil, errors.New("failed to save bookmark")
+	}
+
+	return &bookmark, nil
+}
+
+// RemoveBookmark removes a bookmark of the user, removing one that does not exist is not an error
+func (s *BookmarkService) RemoveBookmark(userID, bookmarkableType, bookmarkableID string) error {
+	err := database.DB.Where("user_id = ? AND bookmarkable_id = ? AND bookmarkable_type = ?",
+		userID, bookmarkableID, bookmarkableType).
+		Delete(&models.Bookmark{}).Error
+	if err != nil {
+		return errors.New("failed to remove bookmark")
+	}
+
+	return nil
+}
+
+// GetBookmarks returns a page of the users bookmarks, most recently saved first
+func (s *BookmarkService) GetBookmarks(userID string, filter BookmarkFilter, cursor string, limit int) (*BookmarkPage, error) {
+	page := BookmarkPage{Bookmarks: []BookmarkWithTarget{}}
+
+	query := database.DB.Model(&models.Bookmark{}).Where("user_id = ?", userID)
+	if filter.Type != "" {
+		query = query.Where("bookmarkable_type = ?", filter.Type)
+	}
+	if filter.Folder != nil {
+		query = query.Where("folder = ?", strings.TrimSpace(*filter.Folder))
+	}
+	if filter.Tag != "" {
+		// jsonb containment - tags @> '["tag"]'
+		tag, _ := json.Marshal([]string{strings.ToLower(strings.TrimSpace(filter.Tag))})
+		query = query.Where("tags @> ?::jsonb", string(tag))
+	}
+
+	if cursor != "" {
+		at, id, err := helpers.DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("(created_at, id) < (?, ?)", at, id)
+	}
+
+	// One extra row tells whether there is another page
+	var bookmarks []models.Bookmark
+	err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&bookmarks).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve bookmarks")
+	}
+
+	if len(bookmarks) > limit {
+		bookmarks = bookmarks[:limit]
+		last := bookmarks[limit-1]
+		page.NextCursor = helpers.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	if err := attachBookmarkTargets(bookmarks, &page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// GetBookmarkFolders lists the folders the user has used, with how many bookmarks are in each
+func (s *BookmarkService) GetBookmarkFolders(userID string) ([]BookmarkFolder, error) {
+	folders := []BookmarkFolder{}
+
+	err := database.DB.Model(&models.Bookmark{}).
+		Select("folder, COUNT(*) AS count").
+		Where("user_id = ?", userID).
+		Group("folder").
+		Order("folder ASC").
+		Scan(&folders).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve bookmark folders")
+	}
+
+	return folders, nil
+}
+
+// attachBookmarkTargets loads the posts and comments of a page of bookmarks with one query each
+func attachBookmarkTargets(bookmarks []models.Bookmark, page *BookmarkPage) error {
+	var postIDs, commentIDs []string
+	for _, b := range bookmarks {
+		if b.BookmarkableType == "post" {
+			postIDs = append(postIDs, b.BookmarkableID)
+		} else {
+			commentIDs = append(commentIDs, b.BookmarkableID)
+		}
+	}
+
+	posts := map[string]*models.Post{}
+	if len(postIDs) > 0 {
+		var found []models.Post
+		err := database.DB.Preload("Author").Preload("Topic").Where("id IN ?", postIDs).Find(&found).Error
+		if err != nil {
+			return errors.New("failed to retrieve bookmarks")
+		}
+		for i := range found {
+			posts[found[i].ID] = &found[i]
+		}
+	}
+
+	comments := map[string]*models.Comment{}
+	if len(commentIDs) > 0 {
+		var found []models.Comment
+		err := database.DB.Preload("Author").Preload("Post").Where("id IN ?", commentIDs).Find(&found).Error
+		if err != nil {
+			return errors.New("failed to retrieve bookmarks")
+		}
+		for i := range found {
+			comments[found[i].ID] = &found[i]
+		}
+	}
+
+	for _, b := range bookmarks {
+		page.Bookmarks = append(page.Bookmarks, BookmarkWithTarget{
+			Bookmark: b,
+			Post:     posts[b.BookmarkableID],
+			Comment:  comments[b.BookmarkableID],
+		})
+	}
+
+	return nil
+}
+
+// bookmarkableExists checks the post or comment exists and is published
+func bookmarkableExists(bookmarkableType, bookmarkableID string) error {
+	var count int64
+	var err error
+	if bookmarkableType == "post" {
+		err = database.DB.Model(&models.Post{}).
+			Where("id = ? AND status = ?", bookmarkableID, models.PostStatusPublished).
+			Count(&count).Error
+	} else {
+		err = database.DB.Model(&models.Comment{}).
+			Joins("JOIN posts ON posts.id = comments.post_id").
+			Where("comments.id = ? AND comments.is_held = ? AND posts.status = ?", bookmarkableID, false, models.PostStatusPublished).
+			Count(&count).Error
+	}
+	if err != nil {
+		return errors.New("database error")
+	}
+	if count == 0 {
+		return errors.New(bookmarkableType + " not found")
+	}
+	return nil
+}
+
+// normalizeBookmarkTags lower cases, trims and dedupes tags, keeping their order
+func normalizeBookmarkTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxBookmarkTag {
+			return nil, errors.New("tag is too long")
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxBookmarkTags {
+		return nil, errors.New("too many tags")
+	}
+	return normalized, nil
+}
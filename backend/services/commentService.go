@@ -2,11 +2,13 @@ package services
 
 import (
 	"errors"
-	"strings"
+	"log"
+	"time"
 
+	"github.com/Kk120306/cvwo-2026/backend/cache"
+	"github.com/Kk120306/cvwo-2026/backend/content"
 	"github.com/Kk120306/cvwo-2026/backend/database"
 	"github.com/Kk120306/cvwo-2026/backend/models"
-	"github.com/microcosm-cc/bluemonday"
 	"gorm.io/gorm"
 )
 
@@ -24,6 +26,12 @@ type CommentWithVotes struct {
 	Likes    int64   `json:"likes"`
 	Dislikes int64   `json:"dislikes"`
 	MyVote   *string `json:"myVote,omitempty"`
+	// Per emoji counts and whether the caller reacted, filled in after the main query
+	Reactions []ReactionSummary `gorm:"-" json:"reactions"`
+	// Whether the caller saved the comment, always false when logged out
+	IsBookmarked bool `json:"isBookmarked"`
+	// Posted by someone else since the callers last visit to the thread
+	IsNew bool `gorm:"-" json:"isNew"`
 }
 
 // CreateCommentInput represents the data needed to create a comment
@@ -31,11 +39,14 @@ type CreateCommentInput struct {
 	PostID   string
 	AuthorID string
 	Content  string
+	Format   string // html (default) or markdown
 }
 
 // UpdateCommentInput represents the data needed to update a comment
 type UpdateCommentInput struct {
-	Content string
+	Content  string
+	Format   string // html (default) or markdown
+	EditorID string // author or the admin making the edit
 }
 
 // TogglePinInput represents the data needed to toggle pin status
@@ -45,95 +56,142 @@ type TogglePinInput struct {
 }
 
 // GetCommentsByPost retrieves all comments for a specific post with vote counts
-func (s *CommentService) GetCommentsByPost(postID string, userID *string) ([]CommentWithVotes, error) {
+// For a logged in user the thread is marked read, newSince is where their current visit started
+// and comments after it are flagged new - nil on their first visit. viewer is nil for guests
+func (s *CommentService) GetCommentsByPost(postID string, viewer *models.User) ([]CommentWithVotes, *time.Time, error) {
 	// Create slice to hold comments
 	var comments []CommentWithVotes
 
 	// Check if user is authenticated to join user votes
+	var userID *string
 	var joinUserVote bool
-	if userID != nil && *userID != "" {
+	if viewer != nil && viewer.ID != "" {
+		userID = &viewer.ID
 		joinUserVote = true
 	}
 
-	// Even if user is not logged in we still want the likes and dislikes count for each comment
-	selectStr := `
-		comments.*,
-		COALESCE(SUM(CASE WHEN votes.vote_type = 'like' THEN 1 ELSE 0 END), 0) AS likes,
-		COALESCE(SUM(CASE WHEN votes.vote_type = 'dislike' THEN 1 ELSE 0 END), 0) AS dislikes
-	`
-
-	// If user is logged in, we add the my_vote field which is used to show what vote the user has made
-	if joinUserVote {
-		selectStr += `,
-			MAX(user_votes.vote_type) AS my_vote`
+	// The thread of a post that is not published is only there for its author and admins
+	var post models.Post
+	if err := database.DB.Select("id", "author_id", "status").First(&post, "id = ?", postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("post not found")
+		}
+		return nil, nil, errors.New("failed to retrieve comments")
+	}
+	if post.Status != models.PostStatusPublished && (viewer == nil || (viewer.ID != post.AuthorID && !viewer.IsAdmin)) {
+		return nil, nil, errors.New("post not found")
 	}
 
-	// The query to pass
-	// For comments that belong to the postID, attach all votes that belong to each comment
-	// Then use selectStr to get the counts. Since LEFT JOIN is used, Coalesce is used in select str
-	query := database.DB.
-		Model(&models.Comment{}).
-		Select(selectStr).
-		Joins(`
-			LEFT JOIN votes 
-			ON votes.votable_id = comments.id 
-			AND votes.votable_type = 'comment'
-		`).
+	// Taken before the query so a comment that lands while it runs still counts as unread
+	readAt := time.Now()
+
+	// The query to pass - comments that belong to the postID
+	query := commentsWithVotesQuery(userID).
 		Where("comments.post_id = ?", postID)
 
-	// if user is logged in, combine a second join where we only want to join votes that belong to the user
+	// Comments by users they blocked are left out, held comments are only shown to their author
 	if joinUserVote {
-		query = query.Joins(`
-			LEFT JOIN votes AS user_votes
-			ON user_votes.votable_id = comments.id
-			AND user_votes.votable_type = 'comment'
-			AND user_votes.user_id = ?
-		`, *userID)
+		query = excludeBlockedAuthors(query, "comments", *userID).
+			Where("comments.is_held = ? OR comments.author_id = ?", false, *userID)
+	} else {
+		query = query.Where("comments.is_held = ?", false)
 	}
 
 	// Execute query
-	// Get any details about Author of comment
-	// Group so that there are no duplicate comments from Join
-	// So that for each comment we get the total and not each vote row
-	// Also for repeated in user_votes if user exists
 	result := query.
-		Preload("Author").
-		Group("comments.id").
 		Order("comments.created_at asc").
 		Find(&comments)
 
 	// If database error
 	if result.Error != nil {
-		return nil, errors.New("failed to retrieve comments")
+		return nil, nil, errors.New("failed to retrieve comments")
+	}
+
+	if err := attachCommentReactions(comments, userID); err != nil {
+		return nil, nil, err
+	}
+
+	if !joinUserVote {
+		return comments, nil, nil
+	}
+
+	newSince, err := markThreadRead(*userID, postID, readAt)
+	if err != nil {
+		log.Printf("read marker error: %v", err)
+		return comments, nil, nil
+	}
+
+	if newSince != nil {
+		for i := range comments {
+			comments[i].IsNew = comments[i].CreatedAt.After(*newSince) && comments[i].AuthorID != *userID
+		}
 	}
 
-	return comments, nil
+	return comments, newSince, nil
 }
 
 // CreateComment creates a new comment under a post
 func (s *CommentService) CreateComment(input CreateCommentInput) (*models.Comment, error) {
-	// Validate content is not empty after trimming
-	if strings.TrimSpace(input.Content) == "" {
-		return nil, errors.New("content cannot be empty")
+	// Sanitize content from the rich text editor, or render it first for markdown
+	body, err := content.Clean(input.Format, input.Content, content.CommentLimits)
+	if err != nil {
+		return nil, err
 	}
-
-	// Sanitize content from the rich text editor
-	// https://github.com/microcosm-cc/bluemonday - prevent XSS attacks
-	safeContent := bluemonday.UGCPolicy().Sanitize(input.Content)
+	safeContent := body.HTML
 
 	// Create comment object
 	comment := models.Comment{
 		PostID:   input.PostID,
 		AuthorID: input.AuthorID,
-		Content:  safeContent,
+		Format:   body.Format,
 	}
 
-	// Insert into DB
-	createErr := database.DB.Create(&comment).Error
+	// Insert into DB - unless the thread was locked, checked in the same transaction
+	var hits []models.FilterHit
+	createErr := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureCanPost(tx, input.AuthorID); err != nil {
+			return err
+		}
+		if err := ensureThreadOpen(tx, "post", input.PostID); err != nil {
+			return err
+		}
+		// Blocked users can not comment on the blockers posts or mention them
+		var post models.Post
+		if err := tx.Select("author_id").First(&post, "id = ?", input.PostID).Error; err != nil {
+			return errors.New("failed to create comment")
+		}
+		if err := ensureNotBlocked(tx, input.AuthorID, post.AuthorID, safeContent); err != nil {
+			return err
+		}
+
+		// Checked last so only comments that would otherwise be saved count as a hit
+		// Masks rewrite the content, held comments are only shown to their author until an admin approves them
+		held, filterHits, err := applyContentFilter(tx, filterTarget{Type: "comment", AuthorID: input.AuthorID, Body: body})
+		hits = filterHits
+		if err != nil {
+			return err
+		}
+		safeContent = body.HTML
+		comment.Content = safeContent
+		comment.Source = body.Source
+		comment.LinkURL = linkURL(safeContent)
+		comment.IsHeld = held
+
+		if err := tx.Create(&comment).Error; err != nil {
+			return errors.New("failed to create comment")
+		}
+		return nil
+	})
+	// kept even when the filter rejected the comment and rolled the transaction back
+	recordFilterHits(hits)
 	if createErr != nil {
-		return nil, errors.New("failed to create comment")
+		return nil, createErr
 	}
 
+	// The topic list shows the latest activity of each topic
+	cache.Invalidate(cache.TopicsKey)
+	queueLinkPreview(safeContent)
+
 	// Fetch the created comment with author
 	fetchErr := database.DB.Preload("Author").Where("id = ?", comment.ID).First(&comment).Error
 	if fetchErr != nil {
@@ -160,40 +218,100 @@ func (s *CommentService) FindCommentByID(commentID string) (*models.Comment, err
 	return &comment, nil
 }
 
-// UpdateComment updates a comment's content
+// UpdateComment updates a comment's content and records the edit as a revision
 func (s *CommentService) UpdateComment(comment *models.Comment, input UpdateCommentInput) error {
-	// Validate content is not empty after trimming
-	if strings.TrimSpace(input.Content) == "" {
-		return errors.New("content cannot be empty")
+	// Sanitize content
+	body, err := content.Clean(input.Format, input.Content, content.CommentLimits)
+	if err != nil {
+		return err
 	}
+	safeContent := body.HTML
 
-	// Sanitize content
-	safeContent := bluemonday.UGCPolicy().Sanitize(input.Content)
+	if err := ensureCanPost(database.DB, input.EditorID); err != nil {
+		return err
+	}
+	if err := ensureNotBlocked(database.DB, input.EditorID, "", safeContent); err != nil {
+		return err
+	}
+
+	held, hits, err := applyContentFilter(database.DB, filterTarget{Type: "comment", ID: comment.ID, AuthorID: comment.AuthorID, EditorID: input.EditorID, Body: body})
+	recordFilterHits(hits)
+	if err != nil {
+		return err
+	}
+	safeContent = body.HTML
 
 	// Update the content
-	save := database.DB.Model(comment).Update("content", safeContent)
-	if save.Error != nil {
+	var updated *models.Comment
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		updated, err = editComment(tx, comment.ID, input.EditorID, revisionSnapshot{
+			Content: safeContent,
+			Format:  body.Format,
+			Source:  body.Source,
+		}, nil)
+		if err != nil {
+			return err
+		}
+
+		// Back to the queue, approving it is up to an admin
+		if held && !updated.IsHeld {
+			if err := tx.Model(updated).Update("is_held", true).Error; err != nil {
+				return err
+			}
+			updated.IsHeld = true
+		}
+
+		// An admin editing someone elses comment is a privileged action
+		if input.EditorID != comment.AuthorID {
+			return recordAudit(tx, AuditEntry{
+				ActorID:    input.EditorID,
+				Action:     "comment.update",
+				TargetType: "comment",
+				TargetID:   comment.ID,
+				Before:     commentSnapshot(comment),
+				After:      commentSnapshot(updated),
+			})
+		}
+		return nil
+	})
+	if err != nil {
 		return errors.New("failed to update comment")
 	}
 
+	// Callers respond with the comment they passed in
+	comment.Content = updated.Content
+	comment.Format = updated.Format
+	comment.Source = updated.Source
+	comment.IsHeld = updated.IsHeld
+	queueLinkPreview(safeContent)
+	comment.EditedAt = updated.EditedAt
+	comment.UpdatedAt = updated.UpdatedAt
+
 	return nil
 }
 
-// DeleteComment deletes a comment and all its votes
-func (s *CommentService) DeleteComment(comment *models.Comment) error {
+// DeleteComment deletes a comment and all its votes, reactions, revisions and bookmarks
+// An admin removing someone elses comment is recorded in the audit log with reason
+func (s *CommentService) DeleteComment(comment *models.Comment, actor *models.User, reason string) error {
 	// Transaction to delete votes and comment - ensures that every operation happens or none at all
 	// https://gorm.io/docs/transactions.html
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		// 1. Delete all votes on this comment (polymorphic relationship)
-		err := tx.Where("votable_id = ? AND votable_type = ?", comment.ID, "comment").Delete(&models.Vote{}).Error
-		if err != nil {
+		// 1. Delete the comment with its votes, reactions, edit history and bookmarks
+		if err := deleteCommentsTx(tx, []string{comment.ID}); err != nil {
 			return err
 		}
 
-		// 2. Delete the comment itself
-		delErr := tx.Delete(comment).Error
-		if delErr != nil {
-			return delErr
+		// 2. Audit when it was not the authors own comment
+		if actor.ID != comment.AuthorID {
+			return recordAudit(tx, AuditEntry{
+				ActorID:    actor.ID,
+				Action:     "comment.delete",
+				TargetType: "comment",
+				TargetID:   comment.ID,
+				Before:     commentSnapshot(comment),
+				Reason:     reason,
+			})
 		}
 
 		return nil
@@ -203,6 +321,9 @@ func (s *CommentService) DeleteComment(comment *models.Comment) error {
 		return errors.New("failed to delete comment")
 	}
 
+	// Cached counts of the comment are gone with it, the topic list shows comment stats
+	cache.Invalidate(cache.VoteCountKey("comment", comment.ID), cache.TopicsKey)
+
 	return nil
 }
 
@@ -212,11 +333,11 @@ func (s *CommentService) CanUserModifyComment(user *models.User, comment *models
 	return user.ID == comment.AuthorID || user.IsAdmin
 }
 
-// PostExists checks if a post exists by ID
+// PostExists checks if a published post exists by ID
 func (s *CommentService) PostExists(postID string) (bool, error) {
-	// Check if post exists
+	// Check if post exists - drafts and scheduled posts can not be commented on
 	var post models.Post
-	if err := database.DB.First(&post, "id = ?", postID).Error; err != nil {
+	if err := database.DB.First(&post, "id = ? AND status = ?", postID, models.PostStatusPublished).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return false, nil
 		}
@@ -224,3 +345,84 @@ func (s *CommentService) PostExists(postID string) (bool, error) {
 	}
 	return true, nil
 }
+
+// deleteCommentsTx deletes comments along with their votes, reactions, edit history and bookmarks
+func deleteCommentsTx(tx *gorm.DB, commentIDs []string) error {
+	if len(commentIDs) == 0 {
+		return nil
+	}
+
+	// Delete all votes on the comments (polymorphic relationship)
+	err := tx.Where("votable_id IN ? AND votable_type = ?", commentIDs, "comment").Delete(&models.Vote{}).Error
+	if err != nil {
+		return err
+	}
+
+	// and their reactions
+	reactionErr := tx.Where("reactable_id IN ? AND reactable_type = ?", commentIDs, "comment").Delete(&models.Reaction{}).Error
+	if reactionErr != nil {
+		return reactionErr
+	}
+
+	// and their edit history
+	revisionErr := tx.Where("revisable_id IN ? AND revisable_type = ?", commentIDs, "comment").Delete(&models.Revision{}).Error
+	if revisionErr != nil {
+		return revisionErr
+	}
+
+	// and anyone's bookmarks of them
+	bookmarkErr := tx.Where("bookmarkable_id IN ? AND bookmarkable_type = ?", commentIDs, "comment").Delete(&models.Bookmark{}).Error
+	if bookmarkErr != nil {
+		return bookmarkErr
+	}
+
+	// Finally the comments themselves
+	return tx.Where("id IN ?", commentIDs).Delete(&models.Comment{}).Error
+}
+
+// commentsWithVotesQuery selects comments with their vote counts and author
+// With a user it also joins their own vote on each comment as my_vote and whether they saved it
+func commentsWithVotesQuery(userID *string) *gorm.DB {
+	// Even if user is not logged in we still want the likes and dislikes count for each comment
+	// These come from the counters stored on each comment
+	selectStr := `
+		comments.*,
+		comments.like_count AS likes,
+		comments.dislike_count AS dislikes
+	`
+
+	joinUserVote := userID != nil && *userID != ""
+
+	// If user is logged in, we add the my_vote field which is used to show what vote the user has made
+	// and whether they saved it
+	if joinUserVote {
+		selectStr += `,
+			user_votes.vote_type AS my_vote,
+			user_bookmarks.id IS NOT NULL AS is_bookmarked`
+	}
+
+	query := database.DB.
+		Model(&models.Comment{}).
+		Select(selectStr)
+
+	// if user is logged in, join only the vote and bookmark that belong to the user
+	// unique_vote and unique_bookmark mean at most one row each per comment so no grouping is needed
+	if joinUserVote {
+		query = query.Joins(`
+			LEFT JOIN votes AS user_votes
+			ON user_votes.votable_id = comments.id
+			AND user_votes.votable_type = 'comment'
+			AND user_votes.user_id = ?
+		`, *userID).
+			Joins(`
+			LEFT JOIN bookmarks AS user_bookmarks
+			ON user_bookmarks.bookmarkable_id = comments.id
+			AND user_bookmarks.bookmarkable_type = 'comment'
+			AND user_bookmarks.user_id = ?
+		`, *userID)
+	}
+
+	// Get any details about Author of comment, and the preview of its link like posts
+	return query.Preload("Author").
+		Preload("LinkPreview", "title <> ''")
+}
@@ -0,0 +1,636 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Kk120306/cvwo-2026/backend/cache"
+	"github.com/Kk120306/cvwo-2026/backend/content"
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ContentFilterService handles the admin managed content filter and the content it held back
+type ContentFilterService struct{}
+
+// NewContentFilterService creates a new instance of ContentFilterService
+func NewContentFilterService() *ContentFilterService {
+	return &ContentFilterService{}
+}
+
+// FilterRuleWithStats is a rule with how often it matched
+type FilterRuleWithStats struct {
+	models.FilterRule
+	Hits       int64      `json:"hits"`
+	RecentHits int64      `json:"recentHits"` // in the last 24 hours
+	LastHitAt  *time.Time `json:"lastHitAt"`
+}
+
+// CreateFilterRuleInput represents the data needed to add a filter rule
+type CreateFilterRuleInput struct {
+	Kind      string
+	Pattern   string
+	Threshold int
+	Action    string
+	ActorID   string // admin making the change, for the audit log
+}
+
+// UpdateFilterRuleInput represents the changes to a filter rule, nil fields are left alone
+// The kind can not change, a rule of another kind is a new rule
+type UpdateFilterRuleInput struct {
+	Pattern   *string
+	Threshold *int
+	Action    *string
+	IsEnabled *bool
+	ActorID   string // admin making the change, for the audit log
+}
+
+// HeldContent is what the filter held back, waiting for an admin
+type HeldContent struct {
+	Posts    []models.Post    `json:"posts"`
+	Comments []models.Comment `json:"comments"`
+}
+
+const (
+	maxFilterPattern = 500
+	maxHeldItems     = 100
+)
+
+// Compiled patterns of word and regex rules, keyed by kind and pattern
+// Rules are read on every post so they are only compiled the first time they are seen
+var (
+	filterPatternsMu sync.Mutex
+	filterPatterns   = map[string]*regexp.Regexp{}
+)
+
+// GetFilterRules lists every rule including disabled ones, oldest first, with their hit statistics
+func (s *ContentFilterService) GetFilterRules() ([]FilterRuleWithStats, error) {
+	rules := []FilterRuleWithStats{}
+	err := database.DB.Model(&models.FilterRule{}).
+		Select("filter_rules.*, hit_stats.hits, hit_stats.recent_hits, hit_stats.last_hit_at").
+		Joins(`
+			LEFT JOIN LATERAL (
+				SELECT COUNT(*) AS hits,
+					COUNT(*) FILTER (WHERE filter_hits.created_at > ?) AS recent_hits,
+					MAX(filter_hits.created_at) AS last_hit_at
+				FROM filter_hits
+				WHERE filter_hits.rule_id = filter_rules.id
+			) AS hit_stats ON TRUE
+		`, time.Now().Add(-24*time.Hour)).
+		Order("filter_rules.created_at ASC").
+		Find(&rules).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve filter rules")
+	}
+
+	return rules, nil
+}
+
+// CreateFilterRule adds a rule, it applies to everything posted from then on
+func (s *ContentFilterService) CreateFilterRule(input CreateFilterRuleInput) (*models.FilterRule, error) {
+	rule := models.FilterRule{
+		Kind:        input.Kind,
+		Pattern:     input.Pattern,
+		Threshold:   input.Threshold,
+		Action:      input.Action,
+		IsEnabled:   true,
+		CreatedByID: input.ActorID,
+	}
+	if err := validateFilterRule(&rule); err != nil {
+		return nil, err
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&rule).Error; err != nil {
+			return errors.New("failed to create filter rule")
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    input.ActorID,
+			Action:     "filter_rule.create",
+			TargetType: "filter_rule",
+			TargetID:   rule.ID,
+			After:      rule,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Invalidate(cache.FilterRulesKey)
+
+	return &rule, nil
+}
+
+// UpdateFilterRule changes a rule or turns it on / off
+func (s *ContentFilterService) UpdateFilterRule(id string, input UpdateFilterRuleInput) (*models.FilterRule, error) {
+	var rule models.FilterRule
+	err := database.DB.First(&rule, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("filter rule not found")
+		}
+		return nil, errors.New("database error")
+	}
+
+	before := rule
+	if input.Pattern != nil {
+		rule.Pattern = *input.Pattern
+	}
+	if input.Threshold != nil {
+		rule.Threshold = *input.Threshold
+	}
+	if input.Action != nil {
+		rule.Action = *input.Action
+	}
+	if input.IsEnabled != nil {
+		rule.IsEnabled = *input.IsEnabled
+	}
+	if err := validateFilterRule(&rule); err != nil {
+		return nil, err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// A map so turning a rule off is not skipped as a zero value
+		err := tx.Model(&rule).Updates(map[string]interface{}{
+			"pattern":    rule.Pattern,
+			"threshold":  rule.Threshold,
+			"action":     rule.Action,
+			"is_enabled": rule.IsEnabled,
+		}).Error
+		if err != nil {
+			return errors.New("failed to update filter rule")
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    input.ActorID,
+			Action:     "filter_rule.update",
+			TargetType: "filter_rule",
+			TargetID:   id,
+			Before:     before,
+			After:      rule,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Invalidate(cache.FilterRulesKey)
+
+	return &rule, nil
+}
+
+// DeleteFilterRule removes a rule along with its hits, disabling it keeps the statistics
+func (s *ContentFilterService) DeleteFilterRule(id, actorID string) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var rule models.FilterRule
+		err := tx.First(&rule, "id = ?", id).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("filter rule not found")
+			}
+			return errors.New("database error")
+		}
+
+		if err := tx.Where("rule_id = ?", id).Delete(&models.FilterHit{}).Error; err != nil {
+			return errors.New("failed to delete filter rule")
+		}
+		if err := tx.Delete(&rule).Error; err != nil {
+			return errors.New("failed to delete filter rule")
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    actorID,
+			Action:     "filter_rule.delete",
+			TargetType: "filter_rule",
+			TargetID:   id,
+			Before:     rule,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	cache.Invalidate(cache.FilterRulesKey)
+
+	return nil
+}
+
+// GetHeldContent lists the held posts and comments, oldest first so they are worked through in order
+// Rejecting held content is done by deleting it like any other post or comment
+func (s *ContentFilterService) GetHeldContent() (*HeldContent, error) {
+	held := HeldContent{Posts: []models.Post{}, Comments: []models.Comment{}}
+
+	err := database.DB.Preload("Author").Preload("Topic").
+		Where("status = ?", models.PostStatusHeld).
+		Order("updated_at ASC").
+		Limit(maxHeldItems).
+		Find(&held.Posts).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve held content")
+	}
+
+	err = database.DB.Preload("Author").
+		Preload("Post", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "title", "topic_id", "author_id", "status")
+		}).
+		Where("is_held = ?", true).
+		Order("updated_at ASC").
+		Limit(maxHeldItems).
+		Find(&held.Comments).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve held content")
+	}
+
+	return &held, nil
+}
+
+// ApprovePost publishes a held post
+// It keeps its original publish time if it was live before an edit got it held
+func (s *ContentFilterService) ApprovePost(postID, actorID string) (*models.Post, error) {
+	var post models.Post
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, "id = ?", postID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("post not found")
+			}
+			return errors.New("database error")
+		}
+		if post.Status != models.PostStatusHeld {
+			return errors.New("post is not held")
+		}
+
+		before := postSnapshot(&post)
+		now := time.Now()
+		post.Status = models.PostStatusPublished
+		if post.PublishedAt == nil {
+			post.PublishedAt = &now
+		}
+		err = tx.Model(&post).Updates(map[string]interface{}{
+			"status":       post.Status,
+			"published_at": post.PublishedAt,
+		}).Error
+		if err != nil {
+			return errors.New("failed to approve post")
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    actorID,
+			Action:     "post.approve",
+			TargetType: "post",
+			TargetID:   postID,
+			Before:     before,
+			After:      postSnapshot(&post),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cache.InvalidatePrefix(cache.FeedPrefix)
+
+	return &post, nil
+}
+
+// ApproveComment shows a held comment to everyone
+func (s *ContentFilterService) ApproveComment(commentID, actorID string) (*models.Comment, error) {
+	var comment models.Comment
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&comment, "id = ?", commentID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("comment not found")
+			}
+			return errors.New("database error")
+		}
+		if !comment.IsHeld {
+			return errors.New("comment is not held")
+		}
+
+		before := commentSnapshot(&comment)
+		comment.IsHeld = false
+		if err := tx.Model(&comment).Update("is_held", false).Error; err != nil {
+			return errors.New("failed to approve comment")
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    actorID,
+			Action:     "comment.approve",
+			TargetType: "comment",
+			TargetID:   commentID,
+			Before:     before,
+			After:      commentSnapshot(&comment),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The topic list shows the latest activity of each topic
+	cache.Invalidate(cache.TopicsKey)
+
+	return &comment, nil
+}
+
+// filterTarget is a post or comment about to be saved
+// Title and Body are rewritten in place when a mask rule matches
+type filterTarget struct {
+	Type     string        // "post" or "comment"
+	ID       string        // of the post or comment being edited, empty for new ones
+	AuthorID string        // owner of the content, repeat and account age rules look at them
+	EditorID string        // whoever is making an edit, empty for new content
+	Title    *string       // posts only
+	Body     *content.Body // already sanitized
+}
+
+// applyContentFilter runs content through the enabled rules, reading with tx so it can run inside the callers transaction
+// Returns whether the content has to be held and the hits to record with recordFilterHits, also when it was rejected
+// Rejected content comes back as an error. Admins skip the filter, they are the ones cleaning up after it
+func applyContentFilter(tx *gorm.DB, target filterTarget) (bool, []models.FilterHit, error) {
+	var author models.User
+	err := tx.Select("id", "is_admin", "created_at").First(&author, "id = ?", target.AuthorID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil, errors.New("user not found")
+		}
+		return false, nil, errors.New("database error")
+	}
+	if author.IsAdmin {
+		return false, nil, nil
+	}
+	// An admin tidying up someone elses content is not held back either
+	if target.EditorID != "" && target.EditorID != target.AuthorID {
+		var editorIsAdmin bool
+		err := tx.Model(&models.User{}).Select("is_admin").Where("id = ?", target.EditorID).Scan(&editorIsAdmin).Error
+		if err != nil {
+			return false, nil, errors.New("database error")
+		}
+		if editorIsAdmin {
+			return false, nil, nil
+		}
+	}
+
+	rules, err := enabledFilterRules()
+	if err != nil || len(rules) == 0 {
+		return false, nil, err
+	}
+
+	links := content.Links(target.Body.HTML)
+	text := filterText(target, links)
+
+	var hits []models.FilterHit
+	var masks []*regexp.Regexp
+	reject, hold := false, false
+
+	for _, rule := range rules {
+		matched, err := ruleMatches(tx, rule, target, &author, text, links)
+		if err != nil {
+			return false, nil, err
+		}
+		if !matched {
+			continue
+		}
+
+		hits = append(hits, models.FilterHit{
+			RuleID:     rule.ID,
+			UserID:     author.ID,
+			TargetType: target.Type,
+			Action:     rule.Action,
+		})
+
+		switch rule.Action {
+		case models.FilterActionReject:
+			reject = true
+		case models.FilterActionMask:
+			pattern, err := rulePattern(rule.Kind, rule.Pattern)
+			if err != nil {
+				hold = true
+				continue
+			}
+			masks = append(masks, pattern)
+		default:
+			hold = true
+		}
+	}
+
+	if reject {
+		return false, hits, errors.New("content was blocked by the content filter")
+	}
+	if len(masks) == 0 {
+		return hold, hits, nil
+	}
+
+	mask := func(value string) string {
+		for _, pattern := range masks {
+			value = pattern.ReplaceAllStringFunc(value, func(match string) string {
+				return strings.Repeat("*", utf8.RuneCountInString(match))
+			})
+		}
+		return value
+	}
+	if target.Title != nil {
+		*target.Title = mask(*target.Title)
+	}
+	target.Body.HTML = content.MapText(target.Body.HTML, mask)
+	// the markdown is sent back for editing, it must not give away what was masked
+	if target.Body.Source != nil {
+		masked := mask(*target.Body.Source)
+		target.Body.Source = &masked
+	}
+
+	// Masking works one text node at a time - a word split up by formatting or hidden in a link
+	// still matches, so that content waits for an admin instead
+	text = filterText(target, content.Links(target.Body.HTML))
+	for _, pattern := range masks {
+		if pattern.MatchString(text) {
+			hold = true
+		}
+	}
+
+	return hold, hits, nil
+}
+
+// recordFilterHits saves the hits of a filter run, outside any transaction so the hits of rejected content stay
+// Statistics are not worth failing a post over
+func recordFilterHits(hits []models.FilterHit) {
+	if len(hits) == 0 {
+		return
+	}
+	if err := database.DB.Create(&hits).Error; err != nil {
+		log.Printf("failed to record content filter hits: %v", err)
+	}
+}
+
+// filterText is what word and regex rules are matched against - the title, the text and where the links go
+// so a link can not hide behind harmless text
+func filterText(target filterTarget, links []string) string {
+	var text strings.Builder
+	if target.Title != nil {
+		text.WriteString(*target.Title)
+		text.WriteByte('\n')
+	}
+	text.WriteString(content.Text(target.Body.HTML))
+	for _, link := range links {
+		text.WriteByte('\n')
+		text.WriteString(link)
+	}
+	return text.String()
+}
+
+// ruleMatches reports whether a rule trips on the content
+func ruleMatches(tx *gorm.DB, rule models.FilterRule, target filterTarget, author *models.User, text string, links []string) (bool, error) {
+	hours := time.Duration(rule.Threshold) * time.Hour
+
+	switch rule.Kind {
+	case models.FilterKindWord, models.FilterKindRegex:
+		pattern, err := rulePattern(rule.Kind, rule.Pattern)
+		if err != nil {
+			// validated when the rule was saved, only a change of regexp syntax could get here
+			log.Printf("skipping filter rule %s: %v", rule.ID, err)
+			return false, nil
+		}
+		return pattern.MatchString(text), nil
+	case models.FilterKindLinks:
+		return len(links) > rule.Threshold, nil
+	case models.FilterKindNewAccount:
+		return len(links) > 0 && time.Since(author.CreatedAt) < hours, nil
+	case models.FilterKindRepeat:
+		return isRepeatContent(tx, target, time.Now().Add(-hours))
+	}
+
+	return false, nil
+}
+
+// isRepeatContent reports whether the author posted the same content as a post or comment since the given time
+// The post or comment being edited does not count against itself
+func isRepeatContent(tx *gorm.DB, target filterTarget, since time.Time) (bool, error) {
+	for _, targetType := range []string{"post", "comment"} {
+		query := tx.Table(votableTable(targetType)).
+			Where("author_id = ? AND content = ? AND created_at > ?", target.AuthorID, target.Body.HTML, since)
+		if targetType == target.Type && target.ID != "" {
+			query = query.Where("id <> ?", target.ID)
+		}
+
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return false, errors.New("database error")
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// enabledFilterRules returns the rules in effect, served from cache as every post and comment reads them
+func enabledFilterRules() ([]models.FilterRule, error) {
+	var rules []models.FilterRule
+
+	if cache.GetJSON(cache.FilterRulesKey, &rules) {
+		return rules, nil
+	}
+
+	err := database.DB.Where("is_enabled = ?", true).
+		Order("created_at ASC").
+		Find(&rules).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve filter rules")
+	}
+
+	cache.SetJSON(cache.FilterRulesKey, rules)
+
+	return rules, nil
+}
+
+// rulePattern compiles the pattern of a word or regex rule
+// Words match whole words in any case, "ass" does not trip on "class"
+func rulePattern(kind, pattern string) (*regexp.Regexp, error) {
+	key := kind + ":" + pattern
+
+	filterPatternsMu.Lock()
+	defer filterPatternsMu.Unlock()
+
+	if compiled, ok := filterPatterns[key]; ok {
+		return compiled, nil
+	}
+
+	expr := pattern
+	if kind == models.FilterKindWord {
+		// \b only works next to word characters, a word like "$$$" can not have one
+		expr = "(?i)" + regexp.QuoteMeta(pattern)
+		if isWordByte(pattern[0]) {
+			expr = `\b` + expr
+		}
+		if isWordByte(pattern[len(pattern)-1]) {
+			expr += `\b`
+		}
+	}
+
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	filterPatterns[key] = compiled
+
+	return compiled, nil
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// validateFilterRule checks a rule makes sense for its kind and clears the settings its kind does not use
+func validateFilterRule(rule *models.FilterRule) error {
+	switch rule.Kind {
+	case models.FilterKindWord, models.FilterKindRegex:
+		rule.Pattern = strings.TrimSpace(rule.Pattern)
+		rule.Threshold = 0
+		if rule.Pattern == "" {
+			return errors.New("pattern is required")
+		}
+		if utf8.RuneCountInString(rule.Pattern) > maxFilterPattern {
+			return errors.New("pattern is too long")
+		}
+		pattern, err := rulePattern(rule.Kind, rule.Pattern)
+		if err != nil {
+			return errors.New("invalid pattern")
+		}
+		// would trip on everything
+		if pattern.MatchString("") {
+			return errors.New("pattern matches empty text")
+		}
+	case models.FilterKindLinks:
+		rule.Pattern = ""
+		if rule.Threshold < 0 {
+			return errors.New("invalid threshold")
+		}
+	case models.FilterKindRepeat, models.FilterKindNewAccount:
+		rule.Pattern = ""
+		if rule.Threshold < 1 {
+			return errors.New("invalid threshold")
+		}
+	default:
+		return errors.New("invalid rule kind")
+	}
+
+	switch rule.Action {
+	case models.FilterActionReject, models.FilterActionHold:
+	case models.FilterActionMask:
+		if rule.Kind != models.FilterKindWord && rule.Kind != models.FilterKindRegex {
+			return errors.New("only word and regex rules can mask")
+		}
+	default:
+		return errors.New("invalid rule action")
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/Kk120306/cvwo-2026/backend/database"
+)
+
+// HealthService handles liveness and readiness checks
+// Shared between the app (which flips draining on shutdown) and the health controller
+type HealthService struct {
+	draining atomic.Bool
+}
+
+// NewHealthService creates a new instance of HealthService
+func NewHealthService() *HealthService {
+	return &HealthService{}
+}
+
+// HealthStatus represents the result of a health check
+type HealthStatus struct {
+	Status   string            `json:"status"`
+	Checks   map[string]string `json:"checks"`
+	Draining bool              `json:"draining"`
+}
+
+// how long a single DB ping may take before we treat the database as down
+const pingTimeout = 2 * time.Second
+
+// StartDraining marks the server as shutting down so readiness starts failing
+func (s *HealthService) StartDraining() {
+	s.draining.Store(true)
+}
+
+// IsDraining reports whether the server is shutting down
+func (s *HealthService) IsDraining() bool {
+	return s.draining.Load()
+}
+
+// Liveness reports whether the process is up - DB state is reported but never fails the check
+// so a database outage does not get the container restarted in a loop
+func (s *HealthService) Liveness(ctx context.Context) HealthStatus {
+	return HealthStatus{
+		Status:   "ok",
+		Checks:   s.runChecks(ctx),
+		Draining: s.IsDraining(),
+	}
+}
+
+// Readiness reports whether the server should receive traffic
+func (s *HealthService) Readiness(ctx context.Context) (HealthStatus, error) {
+	checks := s.runChecks(ctx)
+	status := HealthStatus{
+		Status:   "ok",
+		Checks:   checks,
+		Draining: s.IsDraining(),
+	}
+
+	// Fail as soon as shutdown starts so no new traffic is routed here while draining
+	if status.Draining {
+		status.Status = "draining"
+		return status, errors.New("server is shutting down")
+	}
+
+	for _, result := range checks {
+		if result != "ok" {
+			status.Status = "unavailable"
+			return status, errors.New("dependency check failed")
+		}
+	}
+
+	return status, nil
+}
+
+// runChecks checks database connectivity and migration state
+func (s *HealthService) runChecks(ctx context.Context) map[string]string {
+	checks := map[string]string{
+		"database":   "ok",
+		"migrations": "ok",
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	// The endpoints are public, the error can carry hostnames so it only goes to the log
+	if err := database.Ping(pingCtx); err != nil {
+		log.Printf("health check: database ping failed: %v", err)
+		checks["database"] = "unavailable"
+	}
+
+	if !database.Migrated() {
+		checks["migrations"] = "pending"
+	}
+
+	return checks
+}
@@ -3,39 +3,76 @@ package services
 import (
 	"errors"
 
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/Kk120306/cvwo-2026/backend/database"
 	"github.com/Kk120306/cvwo-2026/backend/helpers"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm"
 )
 
 // S3Service handles S3 operations business logic
-type S3Service struct{}
+type S3Service struct {
+	awsConfig config.AWSConfig
+}
 
 // NewS3Service creates a new instance of S3Service
-func NewS3Service() *S3Service {
-	return &S3Service{}
+func NewS3Service(awsConfig config.AWSConfig) *S3Service {
+	return &S3Service{
+		awsConfig: awsConfig,
+	}
 }
 
 // GenerateUploadURL generates a presigned URL for uploading to S3
-func (s *S3Service) GenerateUploadURL() (string, error) {
-	uploadURL, err := helpers.GenerateUploadURL()
+// The image name is recorded against the uploader so nobody else can claim or delete it
+func (s *S3Service) GenerateUploadURL(uploaderID string) (string, error) {
+	uploadURL, imageName, err := helpers.GenerateUploadURL(s.awsConfig)
 	if err != nil {
 		return "", errors.New("failed to generate S3 upload URL")
 	}
 
+	if err := database.DB.Create(&models.Upload{Name: imageName, UploaderID: uploaderID}).Error; err != nil {
+		return "", errors.New("failed to generate S3 upload URL")
+	}
+
 	return uploadURL, nil
 }
 
-// DeleteImage deletes an image from S3 bucket
-func (s *S3Service) DeleteImage(imageName string) error {
+// DeleteImage deletes an image from S3 bucket, only its uploader or an admin can
+func (s *S3Service) DeleteImage(imageName string, user *models.User) error {
 	// Validate image name
 	if imageName == "" {
 		return errors.New("image name is required")
 	}
 
+	if !user.IsAdmin {
+		owned, err := isUploadedBy(database.DB, imageName, user.ID)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			return errors.New("you can not delete this image")
+		}
+	}
+
 	// Deleting image from S3
-	err := helpers.DeleteImage(imageName)
+	err := helpers.DeleteImage(s.awsConfig, imageName)
 	if err != nil {
 		return errors.New("failed to delete image from S3")
 	}
 
+	if err := database.DB.Where("name = ?", imageName).Delete(&models.Upload{}).Error; err != nil {
+		return errors.New("database error")
+	}
+
 	return nil
 }
+
+// isUploadedBy reports whether the user was handed the image name for their upload
+func isUploadedBy(tx *gorm.DB, imageName, userID string) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Upload{}).Where("name = ? AND uploader_id = ?", imageName, userID).Count(&count).Error
+	if err != nil {
+		return false, errors.New("database error")
+	}
+	return count > 0, nil
+}
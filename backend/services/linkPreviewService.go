@@ -0,0 +1,383 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Kk120306/cvwo-2026/backend/cache"
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/Kk120306/cvwo-2026/backend/content"
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"golang.org/x/net/html"
+	"gorm.io/gorm/clause"
+)
+
+// LinkPreviewService fetches OpenGraph metadata for the first link in posts and comments
+// Fetches happen in the background so posting never waits on another site
+// https://ogp.me
+type LinkPreviewService struct {
+	client   *http.Client
+	maxBytes int64
+	ttl      time.Duration
+
+	queue    chan string
+	mu       sync.Mutex
+	queued   map[string]bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+const (
+	previewWorkers   = 2
+	previewQueueSize = 100
+	maxRedirects     = 3
+	maxPreviewTitle  = 300
+	maxPreviewText   = 500
+	maxSiteName      = 100
+)
+
+// linkPreviews is the running service, nil until StartLinkPreviews so seeding and commands never reach out
+var linkPreviews *LinkPreviewService
+
+// NewLinkPreviewService creates a new instance of LinkPreviewService
+// client is what pages are fetched with, nil builds one that can only reach public addresses
+// Tests can pass the client of a local stub server
+func NewLinkPreviewService(cfg config.LinkPreviewConfig, client *http.Client) *LinkPreviewService {
+	if client == nil {
+		client = newPublicClient(cfg.Timeout)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &LinkPreviewService{
+		client:   client,
+		maxBytes: int64(cfg.MaxBytes),
+		ttl:      cfg.TTL,
+		queue:    make(chan string, previewQueueSize),
+		queued:   map[string]bool{},
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// StartLinkPreviews starts fetching previews for new posts and comments
+func StartLinkPreviews(cfg config.LinkPreviewConfig) {
+	linkPreviews = NewLinkPreviewService(cfg, nil)
+	linkPreviews.Start()
+}
+
+// StopLinkPreviews stops the background fetches and waits for them, call before the database pool closes
+func StopLinkPreviews() {
+	if linkPreviews != nil {
+		linkPreviews.Stop()
+	}
+}
+
+// Start runs the workers that fetch queued links
+func (s *LinkPreviewService) Start() {
+	for i := 0; i < previewWorkers; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for {
+				select {
+				case <-s.ctx.Done():
+					return
+				case link := <-s.queue:
+					s.refresh(link)
+				}
+			}
+		}()
+	}
+}
+
+// Stop cancels fetches in progress and waits for the workers to finish
+func (s *LinkPreviewService) Stop() {
+	s.stopOnce.Do(func() {
+		s.cancel()
+		s.wg.Wait()
+	})
+}
+
+// Queue asks for the link to be previewed, a link that is already queued is not queued twice
+// Never blocks, when the queue is full the link is skipped and picked up the next time it is posted
+func (s *LinkPreviewService) Queue(link string) {
+	if link == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queued[link] {
+		return
+	}
+
+	select {
+	case s.queue <- link:
+		s.queued[link] = true
+	default:
+		log.Printf("link preview queue is full, skipping %s", link)
+	}
+}
+
+// refresh fetches and stores the preview of a link unless a fresh one is already stored
+func (s *LinkPreviewService) refresh(link string) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.queued, link)
+		s.mu.Unlock()
+	}()
+
+	var existing models.LinkPreview
+	err := database.DB.Select("fetched_at").Where("url = ?", link).Limit(1).Find(&existing).Error
+	if err != nil {
+		log.Printf("failed to look up link preview for %s: %v", link, err)
+		return
+	}
+	if time.Since(existing.FetchedAt) < s.ttl {
+		return
+	}
+
+	columns := []string{"title", "description", "image_url", "site_name", "fetched_at"}
+	preview, err := s.Fetch(s.ctx, link)
+	if err != nil {
+		if s.ctx.Err() != nil {
+			return // shutting down, try again another time
+		}
+		// A new link is stored without a title so it is not fetched again until it goes stale
+		// one that worked before keeps its old preview over a failure that may not last
+		log.Printf("failed to fetch link preview for %s: %v", link, err)
+		preview = &models.LinkPreview{URL: link, FetchedAt: time.Now()}
+		columns = []string{"fetched_at"}
+	}
+
+	err = database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "url"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(preview).Error
+	if err != nil {
+		log.Printf("failed to save link preview for %s: %v", link, err)
+		return
+	}
+
+	// cached feeds were rendered without the preview
+	cache.InvalidatePrefix(cache.FeedPrefix)
+}
+
+// Fetch gets the page behind the link and reads its preview from the metadata in <head>
+// Falls back to <title> and the description meta tag for pages without OpenGraph tags
+func (s *LinkPreviewService) Fetch(ctx context.Context, link string) (*models.LinkPreview, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", "cvwo-forum-link-preview/1.0")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("not a html page: %q", mediaType)
+	}
+
+	meta := readHeadMeta(io.LimitReader(resp.Body, s.maxBytes))
+
+	preview := models.LinkPreview{
+		URL:         link,
+		Title:       firstNonEmpty(meta["og:title"], meta["twitter:title"], meta["title"]),
+		Description: firstNonEmpty(meta["og:description"], meta["twitter:description"], meta["description"]),
+		SiteName:    meta["og:site_name"],
+		FetchedAt:   time.Now(),
+	}
+	if preview.Title == "" {
+		return nil, errors.New("page has no title")
+	}
+
+	preview.Title = truncateRunes(preview.Title, maxPreviewTitle)
+	preview.Description = truncateRunes(preview.Description, maxPreviewText)
+	preview.SiteName = truncateRunes(preview.SiteName, maxSiteName)
+
+	// relative image paths are relative to where the redirects ended up
+	if image := firstNonEmpty(meta["og:image"], meta["twitter:image"]); image != "" {
+		if u, err := resp.Request.URL.Parse(image); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			preview.ImageURL = u.String()
+		}
+	}
+
+	return &preview, nil
+}
+
+// readHeadMeta collects <title> and the meta tags of a page, stopping at the body
+// Keys are the property or name of the meta tag, the first value of each wins
+func readHeadMeta(r io.Reader) map[string]string {
+	meta := map[string]string{}
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			// end of the page, or of what was read of it
+			return meta
+		case html.TextToken:
+			if inTitle && meta["title"] == "" {
+				meta["title"] = string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return meta
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = true
+			case "body":
+				return meta
+			case "meta":
+				var key, value string
+				for hasAttr {
+					var k, v []byte
+					k, v, hasAttr = tokenizer.TagAttr()
+					switch string(k) {
+					case "property", "name":
+						key = strings.ToLower(string(v))
+					case "content":
+						value = string(v)
+					}
+				}
+				if key != "" && meta[key] == "" {
+					meta[key] = value
+				}
+			}
+		}
+	}
+}
+
+// newPublicClient builds a client that refuses to connect anywhere but public addresses
+// The check runs on the address actually dialed so redirects and DNS answers can not point it inside the network
+func newPublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddr(addrPort.Addr()) {
+				return fmt.Errorf("refusing to connect to %s", addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// a proxy from the environment would do the dialing instead and skip the check
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			// via holds every request so far, the first one included
+			if len(via) > maxRedirects {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.New("redirect to unsupported scheme")
+			}
+			return nil
+		},
+	}
+}
+
+// Ranges that are not private in net/netip terms but still do not belong to the public internet
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // this network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, broadcast included
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, could map to a private IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+}
+
+// isPublicAddr reports whether an address is on the public internet
+// Loopback, private, link local (cloud metadata lives there), multicast and reserved ranges are not
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// queueLinkPreview previews the first link of sanitized content when previews are running
+func queueLinkPreview(sanitized string) {
+	if linkPreviews != nil {
+		linkPreviews.Queue(content.FirstLink(sanitized))
+	}
+}
+
+// linkURL is the link stored on a post or comment for its preview, nil when there is none
+func linkURL(sanitized string) *string {
+	if link := content.FirstLink(sanitized); link != "" {
+		return &link
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func truncateRunes(value string, max int) string {
+	value = strings.ToValidUTF8(value, "")
+	if utf8.RuneCountInString(value) <= max {
+		return value
+	}
+	return string([]rune(value)[:max])
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kk120306/cvwo-2026/backend/config"
+)
+
+// newStubPreviewService fetches from a local stub server, with the redirect rules of the real client
+// the real dialer would refuse the stub as it listens on loopback
+func newStubPreviewService(t *testing.T, maxBytes int, handler http.Handler) (*LinkPreviewService, *httptest.Server) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	client := newPublicClient(5 * time.Second)
+	client.Transport = srv.Client().Transport
+
+	cfg := config.LinkPreviewConfig{Timeout: 5 * time.Second, MaxBytes: maxBytes, TTL: time.Hour}
+	return NewLinkPreviewService(cfg, client), srv
+}
+
+func servePage(contentType, page string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		fmt.Fprint(w, page)
+	}
+}
+
+func TestFetchOpenGraph(t *testing.T) {
+	page := `<html><head>
+		<title>Fallback title</title>
+		<meta property="og:title" content="  OG title ">
+		<meta property="og:description" content="OG description">
+		<meta property="og:site_name" content="Example">
+		<meta property="og:image" content="/images/cover.png">
+		</head><body><meta property="og:title" content="not in head"></body></html>`
+	s, srv := newStubPreviewService(t, 64*1024, servePage("text/html; charset=utf-8", page))
+
+	preview, err := s.Fetch(context.Background(), srv.URL+"/post")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if preview.Title != "OG title" {
+		t.Errorf("title = %q, want %q", preview.Title, "OG title")
+	}
+	if preview.Description != "OG description" {
+		t.Errorf("description = %q", preview.Description)
+	}
+	if preview.SiteName != "Example" {
+		t.Errorf("site name = %q", preview.SiteName)
+	}
+	if want := srv.URL + "/images/cover.png"; preview.ImageURL != want {
+		t.Errorf("image = %q, want %q", preview.ImageURL, want)
+	}
+}
+
+func TestFetchTitleFallback(t *testing.T) {
+	page := `<html><head><title>Plain title</title><meta name="description" content="Plain description"></head></html>`
+	s, srv := newStubPreviewService(t, 64*1024, servePage("text/html", page))
+
+	preview, err := s.Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if preview.Title != "Plain title" || preview.Description != "Plain description" {
+		t.Errorf("got %q / %q, want the <title> and description meta tag", preview.Title, preview.Description)
+	}
+	if preview.ImageURL != "" {
+		t.Errorf("image = %q, want none", preview.ImageURL)
+	}
+}
+
+func TestFetchStopsAtMaxBytes(t *testing.T) {
+	// the title comes after more padding than is read
+	page := `<html><head><meta name="padding" content="` + strings.Repeat("a", 4096) + `">` +
+		`<title>Too late</title></head></html>`
+
+	s, srv := newStubPreviewService(t, 1024, servePage("text/html", page))
+	if _, err := s.Fetch(context.Background(), srv.URL); err == nil {
+		t.Fatal("Fetch read past the byte limit")
+	}
+
+	s, srv = newStubPreviewService(t, 8192, servePage("text/html", page))
+	preview, err := s.Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch with a larger limit: %v", err)
+	}
+	if preview.Title != "Too late" {
+		t.Errorf("title = %q", preview.Title)
+	}
+}
+
+func TestFetchRejectsNonHTML(t *testing.T) {
+	for _, contentType := range []string{"application/json", "image/png", "text/plain", ""} {
+		s, srv := newStubPreviewService(t, 64*1024, servePage(contentType, `<title>Not a page</title>`))
+		if _, err := s.Fetch(context.Background(), srv.URL); err == nil {
+			t.Errorf("Fetch accepted content type %q", contentType)
+		}
+	}
+}
+
+func TestFetchRedirectLimit(t *testing.T) {
+	// /hops/n redirects n more times before the page
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hops, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hops/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if hops > 0 {
+			http.Redirect(w, r, "/hops/"+strconv.Itoa(hops-1), http.StatusFound)
+			return
+		}
+		servePage("text/html", `<title>Landed</title>`)(w, r)
+	})
+	s, srv := newStubPreviewService(t, 64*1024, handler)
+
+	preview, err := s.Fetch(context.Background(), srv.URL+"/hops/"+strconv.Itoa(maxRedirects))
+	if err != nil {
+		t.Fatalf("Fetch with %d redirects: %v", maxRedirects, err)
+	}
+	if preview.Title != "Landed" {
+		t.Errorf("title = %q", preview.Title)
+	}
+
+	if _, err := s.Fetch(context.Background(), srv.URL+"/hops/"+strconv.Itoa(maxRedirects+1)); err == nil {
+		t.Errorf("Fetch followed %d redirects", maxRedirects+1)
+	}
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+
+		{"127.0.0.1", false},       // loopback
+		{"::1", false},             // loopback
+		{"10.1.2.3", false},        // RFC1918
+		{"172.16.0.1", false},      // RFC1918
+		{"172.31.255.255", false},  // RFC1918
+		{"192.168.1.1", false},     // RFC1918
+		{"169.254.169.254", false}, // link local, cloud metadata
+		{"fe80::1", false},         // link local
+		{"100.64.0.1", false},      // CGNAT
+		{"100.127.255.254", false}, // CGNAT
+		{"fc00::1", false},         // unique local
+		{"fd12:3456::1", false},    // unique local
+		{"64:ff9b::7f00:1", false}, // NAT64 of 127.0.0.1
+		{"64:ff9b::808:808", false},
+		{"::ffff:127.0.0.1", false}, // IPv4 mapped loopback
+		{"::ffff:10.0.0.1", false},  // IPv4 mapped RFC1918
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false}, // multicast
+		{"255.255.255.255", false},
+		{"192.0.2.1", false}, // documentation
+	}
+
+	for _, tt := range tests {
+		if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", tt.addr, got, tt.public)
+		}
+	}
+	if isPublicAddr(netip.Addr{}) {
+		t.Error("the zero address counted as public")
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/Kk120306/cvwo-2026/backend/models"
+)
+
+// MessageEvent is what a message stream receives when a message lands in one of the users conversations
+type MessageEvent struct {
+	ConversationID string         `json:"conversationId"`
+	Message        models.Message `json:"message"`
+}
+
+// messageHub fans new messages out to the open streams of the participants
+// It only reaches streams connected to this instance, with several instances clients catch up by polling
+type messageHub struct {
+	mu      sync.Mutex
+	streams map[string]map[chan MessageEvent]struct{}
+	closed  bool
+}
+
+var hub = &messageHub{streams: map[string]map[chan MessageEvent]struct{}{}}
+
+// how many events a stream can fall behind by before it starts missing them
+const streamBuffer = 16
+
+// subscribe opens a stream for the user, the returned function closes it again
+// The channel is closed when the stream is closed or the hub shuts down
+func (h *messageHub) subscribe(userID string) (<-chan MessageEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events := make(chan MessageEvent, streamBuffer)
+	if h.closed {
+		close(events)
+		return events, func() {}
+	}
+
+	if h.streams[userID] == nil {
+		h.streams[userID] = map[chan MessageEvent]struct{}{}
+	}
+	h.streams[userID][events] = struct{}{}
+
+	return events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.streams[userID][events]; !ok {
+			return // already closed by close()
+		}
+		delete(h.streams[userID], events)
+		if len(h.streams[userID]) == 0 {
+			delete(h.streams, userID)
+		}
+		close(events)
+	}
+}
+
+// publish sends the event to every open stream of the users
+// Never blocks, a stream that is too far behind misses the event and picks it up on its next poll
+func (h *messageHub) publish(userIDs []string, event MessageEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, userID := range userIDs {
+		for events := range h.streams[userID] {
+			select {
+			case events <- event:
+			default:
+			}
+		}
+	}
+}
+
+// close ends every open stream, used on shutdown so streams do not hold the server open
+func (h *messageHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for userID, streams := range h.streams {
+		for events := range streams {
+			close(events)
+		}
+		delete(h.streams, userID)
+	}
+}
+
+// CloseMessageStreams ends every open message stream
+func CloseMessageStreams() {
+	hub.close()
+}
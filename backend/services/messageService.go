@@ -0,0 +1,504 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Kk120306/cvwo-2026/backend/content"
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/helpers"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MessageService handles direct messages between users
+type MessageService struct{}
+
+// NewMessageService creates a new instance of MessageService
+func NewMessageService() *MessageService {
+	return &MessageService{}
+}
+
+const (
+	// the creator included
+	maxConversationSize    = 10
+	maxConversationTitle   = 100
+	conversationTitleError = "conversation title is too long"
+)
+
+// ConversationSummary is a conversation in the callers inbox
+type ConversationSummary struct {
+	models.Conversation
+	// Messages by others since the caller last read the conversation
+	UnreadCount int64           `json:"unreadCount"`
+	LastMessage *models.Message `gorm:"-" json:"lastMessage"`
+}
+
+// ConversationPage is one page of the inbox, most recently active first
+type ConversationPage struct {
+	Conversations []ConversationSummary `json:"conversations"`
+	NextCursor    string                `json:"nextCursor"`
+}
+
+// MessagePage is one page of a conversation, newest first
+// NextCursor pages back to older messages, LatestCursor is what to poll with for newer ones
+type MessagePage struct {
+	Messages     []models.Message `json:"messages"`
+	NextCursor   string           `json:"nextCursor"`
+	LatestCursor string           `json:"latestCursor"`
+}
+
+// StartConversationInput represents a user messaging one or more others
+// With one other user their existing conversation is reused, Title is only kept for groups
+type StartConversationInput struct {
+	CreatorID string
+	Usernames []string
+	Title     string
+	Content   string
+}
+
+// StartConversation sends the first message to one or more users
+func (s *MessageService) StartConversation(input StartConversationInput) (*models.Conversation, *models.Message, error) {
+	content, err := sanitizeMessage(input.Content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	title := strings.TrimSpace(input.Title)
+	if utf8.RuneCountInString(title) > maxConversationTitle {
+		return nil, nil, errors.New(conversationTitleError)
+	}
+
+	// unique names, the creator is dropped further down once ids are known
+	seen := map[string]bool{}
+	var usernames []string
+	for _, username := range input.Usernames {
+		username = strings.TrimSpace(username)
+		if username != "" && !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	if len(usernames) == 0 {
+		return nil, nil, errors.New("conversations need at least one other user")
+	}
+	if len(usernames) > maxConversationSize {
+		return nil, nil, errors.New("conversations can have at most 10 people")
+	}
+
+	var conversation models.Conversation
+	var message *models.Message
+	var participantIDs []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureCanPost(tx, input.CreatorID); err != nil {
+			return err
+		}
+
+		var users []models.User
+		err := tx.Where("username IN ? AND id <> ?", usernames, models.DeletedUserID).Find(&users).Error
+		if err != nil {
+			return errors.New("failed to start conversation")
+		}
+		if len(users) != len(usernames) {
+			return errors.New("user not found")
+		}
+
+		var others []string
+		now := time.Now()
+		for _, user := range users {
+			if user.ID == input.CreatorID {
+				continue
+			}
+			// Nobody can read messages to a banned account
+			if user.EffectiveStatus(now) == models.UserStatusBanned {
+				return errors.New("you can not message this user")
+			}
+			others = append(others, user.ID)
+		}
+		if len(others) == 0 {
+			return errors.New("conversations need at least one other user")
+		}
+		if len(others)+1 > maxConversationSize {
+			return errors.New("conversations can have at most 10 people")
+		}
+
+		if err := ensureNoBlockBetween(tx, input.CreatorID, others); err != nil {
+			return err
+		}
+
+		participantIDs = append(others, input.CreatorID)
+		if len(others) == 1 {
+			err = findOrCreateDirectConversation(tx, input.CreatorID, others[0], &conversation)
+		} else {
+			err = createGroupConversation(tx, title, participantIDs, &conversation)
+		}
+		if err != nil {
+			return err
+		}
+
+		message, err = sendMessageTx(tx, conversation.ID, input.CreatorID, content)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hub.publish(participantIDs, MessageEvent{ConversationID: conversation.ID, Message: *message})
+
+	return &conversation, message, nil
+}
+
+// SendMessage adds a message to a conversation the sender is in
+func (s *MessageService) SendMessage(conversationID, senderID, content string) (*models.Message, error) {
+	content, err := sanitizeMessage(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var message *models.Message
+	var participantIDs []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureCanPost(tx, senderID); err != nil {
+			return err
+		}
+
+		participantIDs, err = conversationParticipantIDs(tx, conversationID, senderID)
+		if err != nil {
+			return err
+		}
+
+		var others []string
+		for _, id := range participantIDs {
+			if id != senderID {
+				others = append(others, id)
+			}
+		}
+		if err := ensureNoBlockBetween(tx, senderID, others); err != nil {
+			return err
+		}
+
+		message, err = sendMessageTx(tx, conversationID, senderID, content)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hub.publish(participantIDs, MessageEvent{ConversationID: conversationID, Message: *message})
+
+	return message, nil
+}
+
+// GetConversations returns a page of the users conversations with unread counts and the last message of each
+// Paged by cursor on (last_message_at, id)
+func (s *MessageService) GetConversations(userID, cursor string, limit int) (*ConversationPage, error) {
+	page := ConversationPage{Conversations: []ConversationSummary{}}
+
+	query := database.DB.Model(&models.Conversation{}).
+		Select(`conversations.*, (
+			SELECT COUNT(*) FROM messages
+			WHERE messages.conversation_id = conversations.id
+			AND messages.sender_id <> me.user_id
+			AND (me.last_read_at IS NULL OR messages.created_at > me.last_read_at)
+			AND messages.sender_id NOT IN (SELECT blocked_id FROM user_blocks WHERE blocker_id = me.user_id)
+		) AS unread_count`).
+		Joins("JOIN conversation_participants AS me ON me.conversation_id = conversations.id AND me.user_id = ?", userID)
+
+	if cursor != "" {
+		at, id, err := helpers.DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("(conversations.last_message_at, conversations.id) < (?, ?)", at, id)
+	}
+
+	// One extra row tells whether there is another page
+	err := query.Preload("Participants.User").
+		Order("conversations.last_message_at DESC, conversations.id DESC").
+		Limit(limit + 1).
+		Find(&page.Conversations).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve conversations")
+	}
+
+	if len(page.Conversations) > limit {
+		page.Conversations = page.Conversations[:limit]
+		last := page.Conversations[limit-1]
+		page.NextCursor = helpers.EncodeCursor(last.LastMessageAt, last.ID)
+	}
+
+	if err := attachLastMessages(page.Conversations, userID); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// GetMessages returns a page of a conversation the user is in, newest first
+// cursor pages back through older messages, after returns only messages newer than it for polling
+// Loading the newest messages marks the conversation read
+func (s *MessageService) GetMessages(conversationID, userID, cursor, after string, limit int) (*MessagePage, error) {
+	if cursor != "" && after != "" {
+		return nil, errors.New("use either cursor or after")
+	}
+
+	if _, err := conversationParticipantIDs(database.DB, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	// Taken before the query so a message that lands while it runs still counts as unread
+	readAt := time.Now()
+
+	page := MessagePage{Messages: []models.Message{}}
+	query := database.DB.Preload("Sender").
+		Where("conversation_id = ?", conversationID).
+		Where("sender_id NOT IN (SELECT blocked_id FROM user_blocks WHERE blocker_id = ?)", userID)
+
+	switch {
+	case after != "":
+		at, id, err := helpers.DecodeCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		// oldest first so nothing is skipped when more than a page came in, flipped below
+		err = query.Where("(created_at, id) > (?, ?)", at, id).
+			Order("created_at ASC, id ASC").
+			Limit(limit).
+			Find(&page.Messages).Error
+		if err != nil {
+			return nil, errors.New("failed to retrieve messages")
+		}
+		for i, j := 0, len(page.Messages)-1; i < j; i, j = i+1, j-1 {
+			page.Messages[i], page.Messages[j] = page.Messages[j], page.Messages[i]
+		}
+
+	default:
+		if cursor != "" {
+			at, id, err := helpers.DecodeCursor(cursor)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where("(created_at, id) < (?, ?)", at, id)
+		}
+
+		// One extra row tells whether there is another page
+		err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&page.Messages).Error
+		if err != nil {
+			return nil, errors.New("failed to retrieve messages")
+		}
+		if len(page.Messages) > limit {
+			page.Messages = page.Messages[:limit]
+			last := page.Messages[limit-1]
+			page.NextCursor = helpers.EncodeCursor(last.CreatedAt, last.ID)
+		}
+	}
+
+	if len(page.Messages) > 0 {
+		page.LatestCursor = helpers.EncodeCursor(page.Messages[0].CreatedAt, page.Messages[0].ID)
+	} else {
+		page.LatestCursor = after
+	}
+
+	// Older pages are history, they do not mean the user caught up
+	if cursor == "" {
+		if err := markConversationRead(database.DB, conversationID, userID, readAt); err != nil {
+			return nil, err
+		}
+	}
+
+	return &page, nil
+}
+
+// MarkConversationRead marks everything in the conversation as read, for clients getting messages from the stream
+func (s *MessageService) MarkConversationRead(conversationID, userID string) error {
+	if _, err := conversationParticipantIDs(database.DB, conversationID, userID); err != nil {
+		return err
+	}
+	return markConversationRead(database.DB, conversationID, userID, time.Now())
+}
+
+// GetUnreadCount counts messages by others the user has not read yet across all their conversations
+func (s *MessageService) GetUnreadCount(userID string) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.Message{}).
+		Joins("JOIN conversation_participants AS me ON me.conversation_id = messages.conversation_id AND me.user_id = ?", userID).
+		Where("messages.sender_id <> ?", userID).
+		Where("me.last_read_at IS NULL OR messages.created_at > me.last_read_at").
+		Where("messages.sender_id NOT IN (SELECT blocked_id FROM user_blocks WHERE blocker_id = ?)", userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, errors.New("failed to count unread messages")
+	}
+
+	return count, nil
+}
+
+// Subscribe opens a stream of new messages in the users conversations, the returned function closes it
+func (s *MessageService) Subscribe(userID string) (<-chan MessageEvent, func()) {
+	return hub.subscribe(userID)
+}
+
+// sanitizeMessage cleans message content with the same policy as posts and comments
+func sanitizeMessage(input string) (string, error) {
+	body, err := content.Clean(models.ContentFormatHTML, input, content.MessageLimits)
+	if err != nil {
+		return "", err
+	}
+	return body.HTML, nil
+}
+
+// conversationParticipantIDs returns who is in the conversation
+// Anyone outside it gets "conversation not found" so its existence does not leak
+func conversationParticipantIDs(tx *gorm.DB, conversationID, userID string) ([]string, error) {
+	var ids []string
+	err := tx.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ?", conversationID).
+		Pluck("user_id", &ids).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve conversation")
+	}
+
+	for _, id := range ids {
+		if id == userID {
+			return ids, nil
+		}
+	}
+	return nil, errors.New("conversation not found")
+}
+
+// ensureNoBlockBetween returns an error when userID blocked any of others or was blocked by them
+func ensureNoBlockBetween(tx *gorm.DB, userID string, others []string) error {
+	var count int64
+	err := tx.Model(&models.UserBlock{}).
+		Where("(blocker_id = ? AND blocked_id IN ?) OR (blocked_id = ? AND blocker_id IN ?)",
+			userID, others, userID, others).
+		Count(&count).Error
+	if err != nil {
+		return errors.New("database error")
+	}
+	if count > 0 {
+		return errors.New("you can not message this user")
+	}
+	return nil
+}
+
+// findOrCreateDirectConversation loads the one-to-one conversation of the two users, creating it the first time
+func findOrCreateDirectConversation(tx *gorm.DB, userID, otherID string, conversation *models.Conversation) error {
+	pair := []string{userID, otherID}
+	sort.Strings(pair)
+	directKey := strings.Join(pair, ":")
+
+	// Two users messaging each other at the same time both insert, DoNothing lets one of them win
+	*conversation = models.Conversation{DirectKey: &directKey, LastMessageAt: time.Now()}
+	result := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "direct_key"}}, DoNothing: true}).
+		Create(conversation)
+	if result.Error != nil {
+		return errors.New("failed to start conversation")
+	}
+
+	if result.RowsAffected == 0 {
+		if err := tx.First(conversation, "direct_key = ?", directKey).Error; err != nil {
+			return errors.New("failed to start conversation")
+		}
+		return nil
+	}
+
+	participants := []models.ConversationParticipant{
+		{ConversationID: conversation.ID, UserID: userID},
+		{ConversationID: conversation.ID, UserID: otherID},
+	}
+	if err := tx.Create(&participants).Error; err != nil {
+		return errors.New("failed to start conversation")
+	}
+	return nil
+}
+
+// createGroupConversation creates a new conversation between all of userIDs
+func createGroupConversation(tx *gorm.DB, title string, userIDs []string, conversation *models.Conversation) error {
+	*conversation = models.Conversation{IsGroup: true, Title: title, LastMessageAt: time.Now()}
+	if err := tx.Create(conversation).Error; err != nil {
+		return errors.New("failed to start conversation")
+	}
+
+	participants := make([]models.ConversationParticipant, 0, len(userIDs))
+	for _, id := range userIDs {
+		participants = append(participants, models.ConversationParticipant{ConversationID: conversation.ID, UserID: id})
+	}
+	if err := tx.Create(&participants).Error; err != nil {
+		return errors.New("failed to start conversation")
+	}
+	return nil
+}
+
+// sendMessageTx stores a message, moves the conversation to the top of the inboxes
+// and counts it as read by the sender
+func sendMessageTx(tx *gorm.DB, conversationID, senderID, content string) (*models.Message, error) {
+	message := models.Message{ConversationID: conversationID, SenderID: senderID, Content: content}
+	if err := tx.Create(&message).Error; err != nil {
+		return nil, errors.New("failed to send message")
+	}
+
+	err := tx.Model(&models.Conversation{}).Where("id = ?", conversationID).
+		UpdateColumn("last_message_at", message.CreatedAt).Error
+	if err != nil {
+		return nil, errors.New("failed to send message")
+	}
+	if err := markConversationRead(tx, conversationID, senderID, message.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Preload("Sender").First(&message, "id = ?", message.ID).Error; err != nil {
+		return nil, errors.New("failed to send message")
+	}
+	return &message, nil
+}
+
+// markConversationRead moves the users read position forward to at, never back
+func markConversationRead(tx *gorm.DB, conversationID, userID string, at time.Time) error {
+	err := tx.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+		Where("last_read_at IS NULL OR last_read_at < ?", at).
+		UpdateColumn("last_read_at", at).Error
+	if err != nil {
+		return errors.New("failed to mark conversation read")
+	}
+	return nil
+}
+
+// attachLastMessages fills in the most recent message of each conversation
+// Messages from users the caller blocked are skipped like in the conversation itself
+func attachLastMessages(conversations []ConversationSummary, userID string) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(conversations))
+	for i, conversation := range conversations {
+		ids[i] = conversation.ID
+	}
+
+	// https://www.postgresql.org/docs/current/sql-select.html#SQL-DISTINCT
+	var messages []models.Message
+	err := database.DB.Preload("Sender").
+		Select("DISTINCT ON (conversation_id) *").
+		Where("conversation_id IN ?", ids).
+		Where("sender_id NOT IN (SELECT blocked_id FROM user_blocks WHERE blocker_id = ?)", userID).
+		Order("conversation_id, created_at DESC, id DESC").
+		Find(&messages).Error
+	if err != nil {
+		return errors.New("failed to retrieve conversations")
+	}
+
+	byConversation := make(map[string]*models.Message, len(messages))
+	for i := range messages {
+		byConversation[messages[i].ConversationID] = &messages[i]
+	}
+	for i := range conversations {
+		conversations[i].LastMessage = byConversation[conversations[i].ID]
+	}
+	return nil
+}
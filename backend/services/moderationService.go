@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Kk120306/cvwo-2026/backend/cache"
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ModerationService handles pinning, locking and archiving
+type ModerationService struct{}
+
+// NewModerationService creates a new instance of ModerationService
+func NewModerationService() *ModerationService {
+	return &ModerationService{}
+}
+
+// ModeratePostInput represents a change to the pin / lock state of a post, nil fields are left alone
+type ModeratePostInput struct {
+	IsPinned *bool
+	IsLocked *bool
+	Reason   string
+}
+
+// ModeratePost changes the pin and lock state of a post and records who did it and why
+// The post row is locked so the events line up with the state they produced
+func (s *ModerationService) ModeratePost(postID, moderatorID string, input ModeratePostInput) (*models.Post, error) {
+	if input.IsPinned == nil && input.IsLocked == nil {
+		return nil, errors.New("nothing to change")
+	}
+
+	var post models.Post
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, "id = ?", postID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("post not found")
+			}
+			return errors.New("database error")
+		}
+
+		before := postSnapshot(&post)
+		updates := map[string]interface{}{}
+		var events []models.ModerationEvent
+
+		if input.IsPinned != nil && *input.IsPinned != post.IsPinned {
+			post.IsPinned = *input.IsPinned
+			updates["is_pinned"] = post.IsPinned
+			events = append(events, moderationEvent("post", postID, moderatorID, toggleAction(*input.IsPinned, "pin", "unpin"), input.Reason))
+		}
+		if input.IsLocked != nil && *input.IsLocked != post.IsLocked {
+			post.IsLocked = *input.IsLocked
+			updates["is_locked"] = post.IsLocked
+			events = append(events, moderationEvent("post", postID, moderatorID, toggleAction(*input.IsLocked, "lock", "unlock"), input.Reason))
+		}
+
+		// Already in the requested state
+		if len(events) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&models.Post{}).Where("id = ?", postID).Updates(updates).Error; err != nil {
+			return errors.New("failed to update post")
+		}
+		if err := tx.Create(&events).Error; err != nil {
+			return errors.New("failed to record moderation")
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    moderatorID,
+			Action:     "post.moderate",
+			TargetType: "post",
+			TargetID:   postID,
+			Before:     before,
+			After:      postSnapshot(&post),
+			Reason:     input.Reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Pins change the feed order and both show on the post
+	cache.InvalidatePrefix(cache.FeedPrefix)
+
+	return &post, nil
+}
+
+// SetTopicArchived archives or unarchives a topic and records who did it and why
+func (s *ModerationService) SetTopicArchived(slug, moderatorID string, archived bool, reason string) (*models.Topic, error) {
+	slug = strings.ToLower(slug)
+
+	var topic models.Topic
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&topic, "slug = ?", slug).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("topic not found")
+			}
+			return errors.New("database error")
+		}
+
+		if topic.IsArchived == archived {
+			return nil
+		}
+
+		before := topicSnapshot(&topic)
+		if err := tx.Model(&models.Topic{}).Where("id = ?", topic.ID).Update("is_archived", archived).Error; err != nil {
+			return errors.New("failed to update topic")
+		}
+		topic.IsArchived = archived
+
+		action := toggleAction(archived, "archive", "unarchive")
+		event := moderationEvent("topic", topic.ID, moderatorID, action, reason)
+		if err := tx.Create(&event).Error; err != nil {
+			return errors.New("failed to record moderation")
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    moderatorID,
+			Action:     "topic." + action,
+			TargetType: "topic",
+			TargetID:   topic.ID,
+			Before:     before,
+			After:      topicSnapshot(&topic),
+			Reason:     reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateTopicCaches()
+
+	return &topic, nil
+}
+
+// GetModerationHistory lists the moderation events of a post or topic, newest first
+func (s *ModerationService) GetModerationHistory(targetType, targetID string) ([]models.ModerationEvent, error) {
+	events := []models.ModerationEvent{}
+	err := database.DB.Preload("Moderator").
+		Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Order("created_at DESC").
+		Find(&events).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve moderation history")
+	}
+
+	return events, nil
+}
+
+// ensureThreadOpen returns an error when the post a comment or vote goes on is locked or its topic archived
+// targetType is "post" or "comment", for comments the post they are under is checked
+// Reads with FOR SHARE so a lock that is being applied at the same moment is waited for
+func ensureThreadOpen(tx *gorm.DB, targetType, targetID string) error {
+	postID := gorm.Expr("?", targetID)
+	if targetType == "comment" {
+		postID = gorm.Expr("(SELECT post_id FROM comments WHERE id = ?)", targetID)
+	}
+
+	var state struct {
+		IsLocked   bool
+		IsArchived bool
+	}
+	err := tx.Raw(`
+		SELECT posts.is_locked, topics.is_archived
+		FROM posts
+		JOIN topics ON topics.id = posts.topic_id
+		WHERE posts.id = ?
+		FOR SHARE OF posts
+	`, postID).Scan(&state).Error
+	if err != nil {
+		return errors.New("database error")
+	}
+
+	if state.IsLocked {
+		return errors.New("post is locked")
+	}
+	if state.IsArchived {
+		return errors.New("topic is archived")
+	}
+
+	return nil
+}
+
+func moderationEvent(targetType, targetID, moderatorID, action, reason string) models.ModerationEvent {
+	return models.ModerationEvent{
+		TargetID:    targetID,
+		TargetType:  targetType,
+		Action:      action,
+		Reason:      strings.TrimSpace(reason),
+		ModeratorID: moderatorID,
+	}
+}
+
+func toggleAction(on bool, onAction, offAction string) string {
+	if on {
+		return onAction
+	}
+	return offAction
+}
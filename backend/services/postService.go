@@ -2,11 +2,15 @@ package services
 
 import (
 	"errors"
+	"log"
 	"strings"
+	"time"
 
+	"github.com/Kk120306/cvwo-2026/backend/cache"
+	"github.com/Kk120306/cvwo-2026/backend/content"
 	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/helpers"
 	"github.com/Kk120306/cvwo-2026/backend/models"
-	"github.com/microcosm-cc/bluemonday"
 	"gorm.io/gorm"
 )
 
@@ -24,22 +28,37 @@ type PostWithVotes struct {
 	Likes    int64   `json:"likes"`
 	Dislikes int64   `json:"dislikes"`
 	MyVote   *string `json:"myVote,omitempty"`
+	// Per emoji counts and whether the caller reacted, filled in after the main query
+	Reactions []ReactionSummary `gorm:"-" json:"reactions"`
+	// Whether the caller saved the post, always false when logged out
+	IsBookmarked bool `json:"isBookmarked"`
+	// Comments by others since the caller last read the thread, nil when they never opened it
+	UnreadComments *int64 `json:"unreadComments"`
 }
 
 // CreatePostInput represents the data needed to create a post
 type CreatePostInput struct {
-	Title    string
-	Content  string
-	TopicID  string
-	AuthorID string
-	ImageUrl *string
+	Title     string
+	Content   string
+	Format    string // html (default) or markdown
+	TopicID   string
+	AuthorID  string
+	ImageUrl  *string
+	Status    string     // draft, scheduled or published - empty means published
+	PublishAt *time.Time // required when scheduled
 }
 
 // UpdatePostInput represents the data needed to update a post
 type UpdatePostInput struct {
 	Title    string
 	Content  string
+	Format   string // html (default) or markdown, the post can switch between them
 	ImageURL *string
+	EditorID string // author or the admin making the edit
+
+	// Only for posts that are not published yet, nil keeps the current status
+	Status    *string
+	PublishAt *time.Time
 }
 
 // GetAllPosts retrieves all posts across all topics with vote counts
@@ -52,69 +71,95 @@ func (s *PostService) GetAllPosts(userID *string) ([]PostWithVotes, error) {
 		joinUserVote = true
 	}
 
-	// From all posts get the likes and dislikes count
-	selectStr := `
-		posts.*,
-		COALESCE(SUM(CASE WHEN votes.vote_type = 'like' THEN 1 ELSE 0 END), 0) AS likes,
-		COALESCE(SUM(CASE WHEN votes.vote_type = 'dislike' THEN 1 ELSE 0 END), 0) AS dislikes
-	`
-
-	// If user is authenticated, also get their vote on each post
-	if joinUserVote {
-		selectStr += `,
-			MAX(user_votes.vote_type) AS my_vote`
+	// The anonymous feed is the same for everyone so it is served from cache
+	if !joinUserVote && cache.GetJSON(cache.AllPostsKey(), &posts) {
+		return posts, nil
 	}
 
-	// attach votes table where votable_id matches post id and votable_type is post
-	// left joins creates null so coalesce is needed
-	query := database.DB.Model(&models.Post{}).
-		Select(selectStr).
-		Joins(`
-			LEFT JOIN votes 
-			ON votes.votable_id = posts.id 
-			AND votes.votable_type = 'post'
-		`)
-
-	// if user is authenticated, join another table that only has users votes
+	// Drafts and scheduled posts never show up in a feed
+	query := postsWithVotesQuery(userID).
+		Where("posts.status = ?", models.PostStatusPublished)
+
+	// Topics the user muted and users they blocked are left out of their feed
 	if joinUserVote {
-		query = query.Joins(`
-			LEFT JOIN votes AS user_votes
-			ON user_votes.votable_id = posts.id
-			AND user_votes.votable_type = 'post'
-			AND user_votes.user_id = ?
-		`, *userID)
+		query = excludeMutedTopics(query, *userID)
+		query = excludeBlockedAuthors(query, "posts", *userID)
 	}
 
-	// Groups by post id, removes any duplicate from join post and combines rows
 	// Prioritize pinned post first
-	query = query.Preload("Author").
-		Preload("Topic").
-		Group("posts.id").
-		Order("is_pinned DESC, created_at DESC")
+	query = query.Order("is_pinned DESC, published_at DESC")
 
 	err := query.Find(&posts).Error
 	if err != nil {
 		return nil, errors.New("failed to retrieve posts")
 	}
 
+	if err := attachPostReactions(posts, userID); err != nil {
+		return nil, err
+	}
+
+	if !joinUserVote {
+		cache.SetJSON(cache.AllPostsKey(), posts)
+	}
+
 	return posts, nil
 }
 
+// HomeFeedPage is one page of the home feed, NextCursor is empty on the last page
+type HomeFeedPage struct {
+	Posts      []PostWithVotes `json:"posts"`
+	NextCursor string          `json:"nextCursor"`
+}
+
+// GetHomeFeed returns the published posts in topics the user follows and by users they follow, newest first
+// Muted topics are left out even for followed users
+// Paged by cursor on (published_at, id) so later pages cost the same as the first
+func (s *PostService) GetHomeFeed(userID, cursor string, limit int) (*HomeFeedPage, error) {
+	page := HomeFeedPage{Posts: []PostWithVotes{}}
+
+	query := postsWithVotesQuery(&userID).
+		Where("posts.status = ?", models.PostStatusPublished).
+		Where(`(
+			posts.topic_id IN (SELECT topic_id FROM topic_subscriptions WHERE user_id = ? AND mode = ?)
+			OR posts.author_id IN (SELECT followee_id FROM user_follows WHERE follower_id = ?)
+		)`, userID, models.SubscriptionFollow, userID)
+	query = excludeMutedTopics(query, userID)
+	query = excludeBlockedAuthors(query, "posts", userID)
+
+	if cursor != "" {
+		at, id, err := helpers.DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("(posts.published_at, posts.id) < (?, ?)", at, id)
+	}
+
+	// One extra row tells whether there is another page
+	err := query.Order("posts.published_at DESC, posts.id DESC").
+		Limit(limit + 1).
+		Find(&page.Posts).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve posts")
+	}
+
+	if len(page.Posts) > limit {
+		page.Posts = page.Posts[:limit]
+		last := page.Posts[limit-1]
+		page.NextCursor = helpers.EncodeCursor(*last.PublishedAt, last.ID)
+	}
+
+	if err := attachPostReactions(page.Posts, &userID); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
 // GetPostsByTopic retrieves all posts under a specific topic with vote counts
 func (s *PostService) GetPostsByTopic(slug string, userID *string) ([]PostWithVotes, error) {
 	// Normalize slug
 	slug = strings.ToLower(slug)
 
-	// Find topic first
-	var topic models.Topic
-	err := database.DB.First(&topic, "slug = ?", slug).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("topic not found")
-		}
-		return nil, errors.New("failed to retrieve topic")
-	}
-
 	var posts []PostWithVotes
 	var joinUserVote bool
 
@@ -123,73 +168,108 @@ func (s *PostService) GetPostsByTopic(slug string, userID *string) ([]PostWithVo
 		joinUserVote = true
 	}
 
-	// From all posts get the likes and dislikes count
-	selectStr := `
-		posts.*,
-		COALESCE(SUM(CASE WHEN votes.vote_type = 'like' THEN 1 ELSE 0 END), 0) AS likes,
-		COALESCE(SUM(CASE WHEN votes.vote_type = 'dislike' THEN 1 ELSE 0 END), 0) AS dislikes
-	`
+	// The anonymous feed is the same for everyone so it is served from cache
+	if !joinUserVote && cache.GetJSON(cache.TopicPostsKey(slug), &posts) {
+		return posts, nil
+	}
 
-	// If user is authenticated, also get their vote on each post
-	if joinUserVote {
-		selectStr += `,
-			MAX(CASE WHEN user_votes.user_id IS NOT NULL THEN user_votes.vote_type ELSE NULL END) AS my_vote`
+	// Find topic first
+	var topic models.Topic
+	err := database.DB.First(&topic, "slug = ?", slug).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("topic not found")
+		}
+		return nil, errors.New("failed to retrieve topic")
 	}
 
-	// attach votes table where votable_id matches post id and votable_type is post
-	// left joins creates null so coalesce is needed
-	query := database.DB.Model(&models.Post{}).
-		Select(selectStr).
-		Joins(`
-			LEFT JOIN votes 
-			ON votes.votable_id = posts.id 
-			AND votes.votable_type = 'post'
-		`).
-		Where("posts.topic_id = ?", topic.ID)
-
-	// if user is authenticated, join another table that only has users votes
+	query := postsWithVotesQuery(userID).
+		Where("posts.topic_id = ? AND posts.status = ?", topic.ID, models.PostStatusPublished).
+		Order("is_pinned DESC, published_at DESC")
+
+	// Users they blocked are left out
 	if joinUserVote {
-		query = query.Joins(`
-			LEFT JOIN votes AS user_votes
-			ON user_votes.votable_id = posts.id
-			AND user_votes.votable_type = 'post'
-			AND user_votes.user_id = ?
-		`, *userID)
+		query = excludeBlockedAuthors(query, "posts", *userID)
 	}
 
-	// Groups by post id, removes any duplicate from join post and combines rows
-	query = query.Preload("Author").
-		Preload("Topic").
-		Group("posts.id").
-		Order("is_pinned DESC, created_at DESC")
-
 	// Execute query and put results in posts slice
 	findErr := query.Find(&posts).Error
 	if findErr != nil {
 		return nil, errors.New("failed to retrieve posts")
 	}
 
+	if err := attachPostReactions(posts, userID); err != nil {
+		return nil, err
+	}
+
+	if !joinUserVote {
+		cache.SetJSON(cache.TopicPostsKey(slug), posts)
+	}
+
 	return posts, nil
 }
 
 // CreatePost creates a new post under a topic
 func (s *PostService) CreatePost(input CreatePostInput) (*models.Post, error) {
-	// Validate input
-	if strings.TrimSpace(input.Title) == "" || strings.TrimSpace(input.Content) == "" {
-		return nil, errors.New("title and content cannot be empty")
+	// Validate and sanitize input, markdown is rendered first
+	title, err := content.Title(input.Title, content.PostLimits)
+	if err != nil {
+		return nil, err
+	}
+	body, err := content.Clean(input.Format, input.Content, content.PostLimits)
+	if err != nil {
+		return nil, err
+	}
+	safeContent := body.HTML
+
+	state, err := resolvePublishState(input.Status, input.PublishAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Muted, suspended and banned users can not post
+	if err := ensureCanPost(database.DB, input.AuthorID); err != nil {
+		return nil, err
+	}
+	if err := ensureNotBlocked(database.DB, input.AuthorID, "", safeContent); err != nil {
+		return nil, err
+	}
+
+	// Archived topics are read only
+	var topic models.Topic
+	if err := database.DB.Select("is_archived").First(&topic, "id = ?", input.TopicID).Error; err != nil {
+		return nil, errors.New("failed to retrieve topic")
+	}
+	if topic.IsArchived {
+		return nil, errors.New("topic is archived")
 	}
 
-	// Sanitize content input from rich text editor
-	// https://github.com/microcosm-cc/bluemonday - prevent xss attacks
-	safeContent := bluemonday.UGCPolicy().Sanitize(input.Content)
+	// Last so only content that would otherwise be saved counts as a hit
+	// masks rewrite the title and content, held posts stay out of the feeds until an admin approves them
+	held, hits, err := applyContentFilter(database.DB, filterTarget{Type: "post", AuthorID: input.AuthorID, Title: &title, Body: body})
+	recordFilterHits(hits)
+	if err != nil {
+		return nil, err
+	}
+	safeContent = body.HTML
+	// drafts go through the filter again when they are published
+	if held && state.Status != models.PostStatusDraft {
+		state = publishState{Status: models.PostStatusHeld}
+	}
 
 	// Create the post
 	post := models.Post{
-		Title:    input.Title,
-		Content:  safeContent,
-		TopicID:  input.TopicID,
-		AuthorID: input.AuthorID,
-		ImageUrl: input.ImageUrl,
+		Title:       title,
+		Content:     safeContent,
+		Format:      body.Format,
+		Source:      body.Source,
+		LinkURL:     linkURL(safeContent),
+		TopicID:     input.TopicID,
+		AuthorID:    input.AuthorID,
+		ImageUrl:    input.ImageUrl,
+		Status:      state.Status,
+		PublishAt:   state.PublishAt,
+		PublishedAt: state.PublishedAt,
 	}
 
 	// Save to database
@@ -198,68 +278,110 @@ func (s *PostService) CreatePost(input CreatePostInput) (*models.Post, error) {
 		return nil, errors.New("failed to create post")
 	}
 
+	// New post shows up in the feeds
+	if post.Status == models.PostStatusPublished {
+		cache.InvalidatePrefix(cache.FeedPrefix)
+	}
+	queueLinkPreview(safeContent)
+
 	return &post, nil
 }
 
 // GetPostByID retrieves a single post by ID with vote counts
 func (s *PostService) GetPostByID(id string, userID *string) (*PostWithVotes, error) {
 	var post PostWithVotes
-	var joinUserVote bool
 
-	// if user exists store user data
-	if userID != nil && *userID != "" {
-		joinUserVote = true
+	query := postsWithVotesQuery(userID).
+		Where("posts.id = ?", id)
+
+	err := query.First(&post).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("post not found")
+		}
+		return nil, errors.New("failed to retrieve post")
 	}
 
-	// From all posts get the likes and dislikes count
-	selectStr := `
-		posts.*,
-		COALESCE(SUM(CASE WHEN votes.vote_type = 'like' THEN 1 ELSE 0 END), 0) AS likes,
-		COALESCE(SUM(CASE WHEN votes.vote_type = 'dislike' THEN 1 ELSE 0 END), 0) AS dislikes
-	`
+	// Unpublished posts only exist for their author
+	if post.Status != models.PostStatusPublished && (userID == nil || *userID != post.AuthorID) {
+		return nil, errors.New("post not found")
+	}
 
-	// If user is authenticated, also get their vote on the post
-	if joinUserVote {
-		selectStr += `,
-			MAX(CASE WHEN user_votes.user_id IS NOT NULL THEN user_votes.vote_type ELSE NULL END) AS my_vote`
+	single := []PostWithVotes{post}
+	if err := attachPostReactions(single, userID); err != nil {
+		return nil, err
 	}
 
-	// attach votes table where votable_id matches post id and votable_type is post
-	// left joins creates null so coalesce is needed
-	query := database.DB.Model(&models.Post{}).
-		Select(selectStr).
-		Joins(`
-			LEFT JOIN votes 
-			ON votes.votable_id = posts.id 
-			AND votes.votable_type = 'post'
-		`).
-		Where("posts.id = ?", id)
+	// Opening a thread reads it, the response still carries the unread count from before
+	if userID != nil && *userID != "" && post.Status == models.PostStatusPublished {
+		if _, err := markThreadRead(*userID, post.ID, time.Now()); err != nil {
+			log.Printf("read marker error: %v", err)
+		}
+	}
 
-	// if user is authenticated, join another table that only has users votes
-	if joinUserVote {
-		query = query.Joins(`
-			LEFT JOIN votes AS user_votes
-			ON user_votes.votable_id = posts.id
-			AND user_votes.votable_type = 'post'
-			AND user_votes.user_id = ?
-		`, *userID)
+	return &single[0], nil
+}
+
+// GetDrafts lists the draft and scheduled posts of an author, most recently changed first
+func (s *PostService) GetDrafts(authorID string) ([]models.Post, error) {
+	posts := []models.Post{}
+	err := database.DB.Preload("Topic").
+		Where("author_id = ? AND status <> ?", authorID, models.PostStatusPublished).
+		Order("updated_at DESC").
+		Find(&posts).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve drafts")
 	}
 
-	// Groups by post id, removes any duplicate from join post and combines rows
-	// Preload Author and Topic relationships
-	query = query.Preload("Author").
-		Preload("Topic").
-		Group("posts.id")
+	return posts, nil
+}
 
-	err := query.First(&post).Error
+// PublishDuePosts publishes every scheduled post whose time has come and returns their ids
+// It is one UPDATE so running it on several instances at once is safe - a row another instance
+// already published no longer matches the WHERE once its lock is released, so each post goes out exactly once
+func (s *PostService) PublishDuePosts() ([]string, error) {
+	var ids []string
+	err := database.DB.Raw(`
+		UPDATE posts
+		SET status = ?, published_at = publish_at, updated_at = NOW()
+		WHERE status = ? AND publish_at <= NOW()
+		RETURNING id
+	`, models.PostStatusPublished, models.PostStatusScheduled).Scan(&ids).Error
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("post not found")
+		return nil, errors.New("failed to publish scheduled posts")
+	}
+
+	if len(ids) > 0 {
+		cache.InvalidatePrefix(cache.FeedPrefix)
+	}
+
+	return ids, nil
+}
+
+// publishState is the status related columns of a post
+type publishState struct {
+	Status      string
+	PublishAt   *time.Time
+	PublishedAt *time.Time
+}
+
+// resolvePublishState validates a requested status and works out the columns that go with it
+func resolvePublishState(status string, publishAt *time.Time) (publishState, error) {
+	now := time.Now()
+
+	switch status {
+	case "", models.PostStatusPublished:
+		return publishState{Status: models.PostStatusPublished, PublishedAt: &now}, nil
+	case models.PostStatusDraft:
+		return publishState{Status: models.PostStatusDraft}, nil
+	case models.PostStatusScheduled:
+		if publishAt == nil || !publishAt.After(now) {
+			return publishState{}, errors.New("publishAt must be in the future")
 		}
-		return nil, errors.New("failed to retrieve post")
+		return publishState{Status: models.PostStatusScheduled, PublishAt: publishAt}, nil
 	}
 
-	return &post, nil
+	return publishState{}, errors.New("invalid post status")
 }
 
 // FindPostByID finds a post by ID (without vote counts)
@@ -275,77 +397,151 @@ func (s *PostService) FindPostByID(id string) (*models.Post, error) {
 	return &post, nil
 }
 
-// UpdatePost updates a post's content
+// UpdatePost updates a post's content and records the edit as a revision
 func (s *PostService) UpdatePost(post *models.Post, input UpdatePostInput) error {
-	// Validate fields are not empty
-	if strings.TrimSpace(input.Title) == "" || strings.TrimSpace(input.Content) == "" {
-		return errors.New("title and content cannot be empty")
+	// Validate and sanitize input
+	title, err := content.Title(input.Title, content.PostLimits)
+	if err != nil {
+		return err
+	}
+	body, err := content.Clean(input.Format, input.Content, content.PostLimits)
+	if err != nil {
+		return err
+	}
+	safeContent := body.HTML
+
+	// Publishing options can only change before the post goes out
+	// and a held post waits for an admin, its author can not publish it
+	var state *publishState
+	if input.Status != nil {
+		if post.Status == models.PostStatusHeld {
+			return errors.New("post is held for moderation")
+		}
+		if post.Status == models.PostStatusPublished {
+			if *input.Status != models.PostStatusPublished {
+				return errors.New("published posts can not be unpublished")
+			}
+		} else {
+			resolved, err := resolvePublishState(*input.Status, input.PublishAt)
+			if err != nil {
+				return err
+			}
+			state = &resolved
+		}
 	}
 
-	// Sanitize content
-	safeContent := bluemonday.UGCPolicy().Sanitize(input.Content)
-
-	// Update fields - Use map to allow nil values
-	updates := map[string]interface{}{
-		"title":   input.Title,
-		"content": safeContent,
+	if err := ensureCanPost(database.DB, input.EditorID); err != nil {
+		return err
+	}
+	if err := ensureNotBlocked(database.DB, input.EditorID, "", safeContent); err != nil {
+		return err
 	}
 
-	// Handle ImageURL separately to allow setting it to null
-	if input.ImageURL != nil {
-		updates["image_url"] = *input.ImageURL
-	} else {
-		// Explicitly set to null if imageUrl is null
-		updates["image_url"] = nil
+	held, hits, err := applyContentFilter(database.DB, filterTarget{Type: "post", ID: post.ID, AuthorID: post.AuthorID, EditorID: input.EditorID, Title: &title, Body: body})
+	recordFilterHits(hits)
+	if err != nil {
+		return err
+	}
+	safeContent = body.HTML
+
+	// A held post goes back to the queue, drafts are left alone until they are published
+	holdPost := false
+	if held {
+		if state != nil && state.Status != models.PostStatusDraft {
+			state = &publishState{Status: models.PostStatusHeld}
+		} else if state == nil && post.Status != models.PostStatusDraft {
+			holdPost = true
+		}
 	}
 
-	updateErr := database.DB.Model(post).Updates(updates).Error
-	if updateErr != nil {
+	// A nil ImageURL clears the image
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		editErr := editPost(tx, post.ID, input.EditorID, revisionSnapshot{
+			Title:    &title,
+			Content:  safeContent,
+			ImageUrl: input.ImageURL,
+			Format:   body.Format,
+			Source:   body.Source,
+		}, nil)
+		if editErr != nil {
+			return editErr
+		}
+
+		// An admin editing someone elses post is a privileged action
+		if input.EditorID != post.AuthorID {
+			after := postSnapshot(post)
+			after["title"] = title
+			after["content"] = safeContent
+			after["imageUrl"] = input.ImageURL
+
+			auditErr := recordAudit(tx, AuditEntry{
+				ActorID:    input.EditorID,
+				Action:     "post.update",
+				TargetType: "post",
+				TargetID:   post.ID,
+				Before:     postSnapshot(post),
+				After:      after,
+			})
+			if auditErr != nil {
+				return auditErr
+			}
+		}
+
+		// Unlike a status change this also takes back published posts
+		if holdPost {
+			return tx.Model(&models.Post{}).
+				Where("id = ?", post.ID).
+				Updates(map[string]interface{}{
+					"status":     models.PostStatusHeld,
+					"publish_at": nil,
+				}).Error
+		}
+		if state == nil {
+			return nil
+		}
+
+		// Only touches posts that are still unpublished, the scheduler may have got there first
+		return tx.Model(&models.Post{}).
+			Where("id = ? AND status <> ?", post.ID, models.PostStatusPublished).
+			Updates(map[string]interface{}{
+				"status":       state.Status,
+				"publish_at":   state.PublishAt,
+				"published_at": state.PublishedAt,
+			}).Error
+	})
+	if err != nil {
 		return errors.New("failed to update post")
 	}
 
+	cache.InvalidatePrefix(cache.FeedPrefix)
+	queueLinkPreview(safeContent)
+
 	return nil
 }
 
-// DeletePost deletes a post and all associated data (votes, comments, comment votes)
-func (s *PostService) DeletePost(post *models.Post) error {
+// DeletePost deletes a post and all associated data (votes, reactions, revisions, bookmarks, comments and theirs)
+// actor is the user deleting it, an admin removing someone elses post is recorded in the audit log with reason
+func (s *PostService) DeletePost(post *models.Post, actor *models.User, reason string) error {
+	// kept outside the transaction so their cached vote counts can be dropped after
+	var commentIDs []string
+
 	// Transaction to handle all votes, ensures all or no operations happen
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		// Get all comment IDs for this post to delete their votes
-		var commentIDs []string
-		retrieveErr := tx.Model(&models.Comment{}).
-			Where("post_id = ?", post.ID). // Get comments under the post
-			Pluck("id", &commentIDs).Error // Pluck gets a slice of only the ids
-		if retrieveErr != nil {
-			return retrieveErr
-		}
-
-		// Delete votes on comments
-		if len(commentIDs) > 0 {
-			err := tx.Where("votable_id IN ? AND votable_type = ?", commentIDs, "comment").
-				Delete(&models.Vote{}).Error
-			if err != nil {
-				return err
-			}
-		}
-
-		// Delete votes on the post itself
-		err := tx.Where("votable_id = ? AND votable_type = ?", post.ID, "post").
-			Delete(&models.Vote{}).Error
+		var err error
+		commentIDs, err = deletePostsTx(tx, []string{post.ID})
 		if err != nil {
 			return err
 		}
 
-		// Delete comments on the post
-		commentErr := tx.Where("post_id = ?", post.ID).Delete(&models.Comment{}).Error
-		if commentErr != nil {
-			return commentErr
-		}
-
-		// Finally, delete the post itself
-		delError := tx.Delete(post).Error
-		if delError != nil {
-			return delError
+		if actor.ID != post.AuthorID {
+			return recordAudit(tx, AuditEntry{
+				ActorID:    actor.ID,
+				Action:     "post.delete",
+				TargetType: "post",
+				TargetID:   post.ID,
+				Before:     postSnapshot(post),
+				Reason:     reason,
+			})
 		}
 
 		return nil
@@ -355,21 +551,24 @@ func (s *PostService) DeletePost(post *models.Post) error {
 		return errors.New("failed to delete post")
 	}
 
-	return nil
-}
-
-// TogglePinPost toggles the pin status of a post
-func (s *PostService) TogglePinPost(post *models.Post, isPinned bool) error {
-	// Update pin status
-	post.IsPinned = isPinned
-	saveErr := database.DB.Save(post).Error
-	if saveErr != nil {
-		return errors.New("failed to update pin status")
+	// Drop the post from the feeds along with any cached counts of what was removed
+	staleKeys := []string{cache.VoteCountKey("post", post.ID)}
+	for _, commentID := range commentIDs {
+		staleKeys = append(staleKeys, cache.VoteCountKey("comment", commentID))
 	}
+	cache.Invalidate(staleKeys...)
+	cache.InvalidatePrefix(cache.FeedPrefix)
 
 	return nil
 }
 
+// IsVisibleTo reports whether a post can be seen by the user - drafts and scheduled posts only by their author
+// held posts by their author and the admins reviewing them
+func (s *PostService) IsVisibleTo(user *models.User, post *models.Post) bool {
+	return post.Status == models.PostStatusPublished || user.ID == post.AuthorID ||
+		(post.Status == models.PostStatusHeld && user.IsAdmin)
+}
+
 // CanUserModifyPost checks if a user has permission to modify a post
 func (s *PostService) CanUserModifyPost(user *models.User, post *models.Post) bool {
 	// check if user is permitted (either author or admin)
@@ -397,6 +596,7 @@ func (s *PostService) ReloadPostWithRelationships(postID string) (*models.Post,
 	reloadErr := database.DB.
 		Preload("Author").
 		Preload("Topic").
+		Preload("LinkPreview", "title <> ''").
 		Where("id = ?", postID).
 		First(&post).Error
 	if reloadErr != nil {
@@ -404,3 +604,139 @@ func (s *PostService) ReloadPostWithRelationships(postID string) (*models.Post,
 	}
 	return &post, nil
 }
+
+// deletePostsTx deletes posts along with everything under them - comments, votes, reactions,
+// edit history, bookmarks, read positions and moderation history
+// Returns the ids of the comments that went with them so their cached counts can be dropped
+func deletePostsTx(tx *gorm.DB, postIDs []string) ([]string, error) {
+	var commentIDs []string
+	if len(postIDs) == 0 {
+		return commentIDs, nil
+	}
+
+	// Get all comment IDs under the posts to delete them with their votes
+	err := tx.Model(&models.Comment{}).
+		Where("post_id IN ?", postIDs). // Get comments under the posts
+		Pluck("id", &commentIDs).Error  // Pluck gets a slice of only the ids
+	if err != nil {
+		return nil, err
+	}
+
+	if err := deleteCommentsTx(tx, commentIDs); err != nil {
+		return nil, err
+	}
+
+	// Delete votes on the posts themselves
+	err = tx.Where("votable_id IN ? AND votable_type = ?", postIDs, "post").
+		Delete(&models.Vote{}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Delete reactions on the posts
+	reactionErr := tx.Where("reactable_id IN ? AND reactable_type = ?", postIDs, "post").
+		Delete(&models.Reaction{}).Error
+	if reactionErr != nil {
+		return nil, reactionErr
+	}
+
+	// Delete the edit history of the posts
+	revisionErr := tx.Where("revisable_id IN ? AND revisable_type = ?", postIDs, "post").
+		Delete(&models.Revision{}).Error
+	if revisionErr != nil {
+		return nil, revisionErr
+	}
+
+	// Delete bookmarks of the posts
+	bookmarkErr := tx.Where("bookmarkable_id IN ? AND bookmarkable_type = ?", postIDs, "post").
+		Delete(&models.Bookmark{}).Error
+	if bookmarkErr != nil {
+		return nil, bookmarkErr
+	}
+
+	// Delete everyones read position in the threads
+	readErr := tx.Where("post_id IN ?", postIDs).Delete(&models.ReadMarker{}).Error
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	// Delete the moderation history of the posts
+	moderationErr := tx.Where("target_id IN ? AND target_type = ?", postIDs, "post").
+		Delete(&models.ModerationEvent{}).Error
+	if moderationErr != nil {
+		return nil, moderationErr
+	}
+
+	// Finally, delete the posts themselves
+	if err := tx.Where("id IN ?", postIDs).Delete(&models.Post{}).Error; err != nil {
+		return nil, err
+	}
+
+	return commentIDs, nil
+}
+
+// postsWithVotesQuery selects posts with their vote counts, author and topic for the feeds
+// With a user it also joins their own vote on each post as my_vote
+func postsWithVotesQuery(userID *string) *gorm.DB {
+	// Likes and dislikes come from the counters stored on each post
+	selectStr := `
+		posts.*,
+		posts.like_count AS likes,
+		posts.dislike_count AS dislikes
+	`
+
+	joinUserVote := userID != nil && *userID != ""
+
+	// If user is authenticated, also get their vote on each post, whether they saved it
+	// and how many comments came in since they last read it - counted on idx_comments_post_created
+	if joinUserVote {
+		selectStr += `,
+			user_votes.vote_type AS my_vote,
+			user_bookmarks.id IS NOT NULL AS is_bookmarked,
+			CASE WHEN user_reads.id IS NULL THEN NULL ELSE (
+				SELECT COUNT(*) FROM comments
+				WHERE comments.post_id = posts.id
+				AND comments.created_at > user_reads.last_read_at
+				AND comments.author_id <> user_reads.user_id
+				AND comments.is_held = false
+				AND comments.author_id NOT IN (SELECT blocked_id FROM user_blocks WHERE blocker_id = user_reads.user_id)
+			) END AS unread_comments`
+	}
+
+	query := database.DB.Model(&models.Post{}).
+		Select(selectStr)
+
+	// if user is authenticated, join the users own vote, bookmark and read position
+	// the unique indexes on each mean at most one row each per post so no grouping is needed
+	if joinUserVote {
+		query = query.Joins(`
+			LEFT JOIN votes AS user_votes
+			ON user_votes.votable_id = posts.id
+			AND user_votes.votable_type = 'post'
+			AND user_votes.user_id = ?
+		`, *userID).
+			Joins(`
+			LEFT JOIN bookmarks AS user_bookmarks
+			ON user_bookmarks.bookmarkable_id = posts.id
+			AND user_bookmarks.bookmarkable_type = 'post'
+			AND user_bookmarks.user_id = ?
+		`, *userID).
+			Joins(`
+			LEFT JOIN read_markers AS user_reads
+			ON user_reads.post_id = posts.id
+			AND user_reads.user_id = ?
+		`, *userID)
+	}
+
+	// failed fetches are stored without a title and are not shown
+	return query.Preload("Author").
+		Preload("Topic").
+		Preload("LinkPreview", "title <> ''")
+}
+
+// excludeMutedTopics leaves out posts in topics the user has muted
+func excludeMutedTopics(query *gorm.DB, userID string) *gorm.DB {
+	return query.Where(
+		"posts.topic_id NOT IN (SELECT topic_id FROM topic_subscriptions WHERE user_id = ? AND mode = ?)",
+		userID, models.SubscriptionMute)
+}
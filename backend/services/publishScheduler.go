@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// PublishScheduler publishes scheduled posts in the background
+// Every instance runs one, PostService.PublishDuePosts makes sure each post is published only once
+type PublishScheduler struct {
+	interval    time.Duration
+	postService *PostService
+}
+
+// NewPublishScheduler creates a scheduler that checks for due posts every interval
+func NewPublishScheduler(interval time.Duration) *PublishScheduler {
+	return &PublishScheduler{
+		interval:    interval,
+		postService: NewPostService(),
+	}
+}
+
+// Run checks for due posts until ctx is cancelled, meant to be started in its own goroutine
+func (s *PublishScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		// Check straight away so posts that came due while we were down go out on start up
+		ids, err := s.postService.PublishDuePosts()
+		if err != nil {
+			log.Println("Scheduled publishing failed:", err)
+		} else if len(ids) > 0 {
+			log.Printf("Published %d scheduled post(s)", len(ids))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Kk120306/cvwo-2026/backend/cache"
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReactionService handles emoji reactions and the admin managed reaction set
+type ReactionService struct{}
+
+// NewReactionService creates a new instance of ReactionService
+func NewReactionService() *ReactionService {
+	return &ReactionService{}
+}
+
+// ReactionInput represents the data needed to toggle a reaction
+type ReactionInput struct {
+	ReactableID   string
+	ReactableType string
+	Emoji         string
+}
+
+// ReactionSummary is how many users reacted with one emoji and whether the caller is one of them
+type ReactionSummary struct {
+	Emoji   string `json:"emoji"`
+	Count   int64  `json:"count"`
+	Reacted bool   `json:"reacted"`
+}
+
+// CreateReactionTypeInput represents the data needed to add an allowed reaction
+type CreateReactionTypeInput struct {
+	Emoji    string
+	Name     string
+	Position int
+	ActorID  string // admin making the change, for the audit log
+}
+
+// UpdateReactionTypeInput represents the changes to an allowed reaction, nil fields are left alone
+// The emoji itself can not change as existing reactions refer to it
+type UpdateReactionTypeInput struct {
+	Name     *string
+	Position *int
+	IsActive *bool
+	ActorID  string // admin making the change, for the audit log
+}
+
+// shortcode names like "thumbs_up"
+var reactionNamePattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// ValidateReactionInput validates reaction input data
+func (s *ReactionService) ValidateReactionInput(input ReactionInput) error {
+	if input.ReactableType != "post" && input.ReactableType != "comment" {
+		return errors.New("invalid content type")
+	}
+
+	if _, err := uuid.Parse(input.ReactableID); err != nil {
+		return errors.New("invalid content id")
+	}
+
+	if strings.TrimSpace(input.Emoji) == "" {
+		return errors.New("emoji cannot be empty")
+	}
+
+	return nil
+}
+
+// ToggleReaction adds the reaction if the user has not left it yet, otherwise removes it
+// Returns the updated reaction summary of the post / comment
+func (s *ReactionService) ToggleReaction(userID string, input ReactionInput) ([]ReactionSummary, error) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Muted users can not react, unlike votes reactions are visible to everyone
+		if err := ensureCanPost(tx, userID); err != nil {
+			return err
+		}
+
+		// Reactions are frozen along with votes once a thread is locked
+		if err := ensureThreadOpen(tx, input.ReactableType, input.ReactableID); err != nil {
+			return err
+		}
+
+		// Already reacted - remove it, this also works for reactions an admin has since disabled
+		res := tx.Where("user_id = ? AND reactable_id = ? AND reactable_type = ? AND emoji = ?",
+			userID, input.ReactableID, input.ReactableType, input.Emoji).
+			Delete(&models.Reaction{})
+		if res.Error != nil {
+			return errors.New("failed to remove reaction")
+		}
+		if res.RowsAffected > 0 {
+			return nil
+		}
+
+		// Only reactions from the active set can be added
+		var allowed int64
+		err := tx.Model(&models.ReactionType{}).
+			Where("emoji = ? AND is_active = ?", input.Emoji, true).
+			Count(&allowed).Error
+		if err != nil {
+			return errors.New("database error")
+		}
+		if allowed == 0 {
+			return errors.New("reaction not allowed")
+		}
+
+		// Make sure the post / comment exists - like votes, only published posts and the visible comments under them
+		var targetID string
+		if input.ReactableType == "post" {
+			err = tx.Model(&models.Post{}).
+				Select("id").
+				Where("id = ? AND status = ?", input.ReactableID, models.PostStatusPublished).
+				Scan(&targetID).Error
+		} else {
+			err = tx.Model(&models.Comment{}).
+				Joins("JOIN posts ON posts.id = comments.post_id").
+				Select("comments.id").
+				Where("comments.id = ? AND comments.is_held = ? AND posts.status = ?", input.ReactableID, false, models.PostStatusPublished).
+				Scan(&targetID).Error
+		}
+		if err != nil {
+			return errors.New("database error")
+		}
+		if targetID == "" {
+			return errors.New(input.ReactableType + " not found")
+		}
+
+		// ON CONFLICT DO NOTHING - a double click that raced past the delete above is a no-op
+		// https://gorm.io/docs/create.html#Upsert-On-Conflict
+		reaction := models.Reaction{
+			UserID:        userID,
+			ReactableID:   input.ReactableID,
+			ReactableType: input.ReactableType,
+			Emoji:         input.Emoji,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction).Error; err != nil {
+			return errors.New("failed to add reaction")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Post reactions show up in the cached feeds
+	if input.ReactableType == "post" {
+		cache.InvalidatePrefix(cache.FeedPrefix)
+	}
+
+	summaries, err := s.GetReactionSummaries(input.ReactableType, []string{input.ReactableID}, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return summaries[input.ReactableID], nil
+}
+
+// GetReactionSummaries returns the reaction summary of every given post / comment in one query
+// Items without reactions get an empty slice, userID may be nil for anonymous callers
+func (s *ReactionService) GetReactionSummaries(reactableType string, ids []string, userID *string) (map[string][]ReactionSummary, error) {
+	summaries := make(map[string][]ReactionSummary, len(ids))
+	for _, id := range ids {
+		summaries[id] = []ReactionSummary{}
+	}
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+
+	// Whether the caller is one of the users who reacted
+	reactedStr := "FALSE AS reacted"
+	var reactedArgs []interface{}
+	if userID != nil && *userID != "" {
+		reactedStr = "BOOL_OR(reactions.user_id = ?) AS reacted"
+		reactedArgs = append(reactedArgs, *userID)
+	}
+
+	var rows []struct {
+		ReactableID string
+		ReactionSummary
+	}
+
+	// Ordered like the reaction picker, disabled reactions fall to the end
+	err := database.DB.Model(&models.Reaction{}).
+		Select("reactions.reactable_id, reactions.emoji, COUNT(*) AS count, "+reactedStr, reactedArgs...).
+		Joins("LEFT JOIN reaction_types ON reaction_types.emoji = reactions.emoji").
+		Where("reactions.reactable_type = ? AND reactions.reactable_id IN ?", reactableType, ids).
+		Group("reactions.reactable_id, reactions.emoji, reaction_types.position").
+		Order("reaction_types.position ASC NULLS LAST, reactions.emoji ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve reactions")
+	}
+
+	for _, row := range rows {
+		summaries[row.ReactableID] = append(summaries[row.ReactableID], row.ReactionSummary)
+	}
+
+	return summaries, nil
+}
+
+// attachPostReactions fills in the reactions of every post in place
+func attachPostReactions(posts []PostWithVotes, userID *string) error {
+	ids := make([]string, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+
+	summaries, err := NewReactionService().GetReactionSummaries("post", ids, userID)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Reactions = summaries[posts[i].ID]
+	}
+
+	return nil
+}
+
+// attachCommentReactions fills in the reactions of every comment in place
+func attachCommentReactions(comments []CommentWithVotes, userID *string) error {
+	ids := make([]string, len(comments))
+	for i := range comments {
+		ids[i] = comments[i].ID
+	}
+
+	summaries, err := NewReactionService().GetReactionSummaries("comment", ids, userID)
+	if err != nil {
+		return err
+	}
+
+	for i := range comments {
+		comments[i].Reactions = summaries[comments[i].ID]
+	}
+
+	return nil
+}
+
+// GetReactionTypes lists the reactions users can pick from, in picker order
+func (s *ReactionService) GetReactionTypes() ([]models.ReactionType, error) {
+	var reactionTypes []models.ReactionType
+
+	if cache.GetJSON(cache.ReactionTypesKey, &reactionTypes) {
+		return reactionTypes, nil
+	}
+
+	err := database.DB.Where("is_active = ?", true).
+		Order("position ASC, created_at ASC").
+		Find(&reactionTypes).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve reactions")
+	}
+
+	cache.SetJSON(cache.ReactionTypesKey, reactionTypes)
+
+	return reactionTypes, nil
+}
+
+// GetAllReactionTypes lists every reaction including disabled ones, for admins
+func (s *ReactionService) GetAllReactionTypes() ([]models.ReactionType, error) {
+	var reactionTypes []models.ReactionType
+
+	err := database.DB.Order("position ASC, created_at ASC").Find(&reactionTypes).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve reactions")
+	}
+
+	return reactionTypes, nil
+}
+
+// CreateReactionType adds a new reaction to the allowed set
+func (s *ReactionService) CreateReactionType(input CreateReactionTypeInput) (*models.ReactionType, error) {
+	emoji := strings.TrimSpace(input.Emoji)
+	name := strings.ToLower(strings.TrimSpace(input.Name))
+
+	if emoji == "" || len(emoji) > 32 || utf8.RuneCountInString(emoji) > 8 {
+		return nil, errors.New("invalid emoji")
+	}
+	if !reactionNamePattern.MatchString(name) {
+		return nil, errors.New("invalid reaction name")
+	}
+
+	// Check emoji and name are not taken
+	var existing int64
+	err := database.DB.Model(&models.ReactionType{}).
+		Where("emoji = ? OR name = ?", emoji, name).
+		Count(&existing).Error
+	if err != nil {
+		return nil, errors.New("database error")
+	}
+	if existing > 0 {
+		return nil, errors.New("reaction already exists")
+	}
+
+	reactionType := models.ReactionType{
+		Emoji:    emoji,
+		Name:     name,
+		Position: input.Position,
+		IsActive: true,
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&reactionType).Error; err != nil {
+			return errors.New("failed to create reaction")
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    input.ActorID,
+			Action:     "reaction_type.create",
+			TargetType: "reaction_type",
+			TargetID:   reactionType.ID,
+			After:      reactionType,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Invalidate(cache.ReactionTypesKey)
+
+	return &reactionType, nil
+}
+
+// UpdateReactionType renames, reorders or enables / disables an allowed reaction
+// Disabling keeps the existing reactions on content, users just can not add new ones
+func (s *ReactionService) UpdateReactionType(id string, input UpdateReactionTypeInput) (*models.ReactionType, error) {
+	var reactionType models.ReactionType
+	err := database.DB.First(&reactionType, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("reaction not found")
+		}
+		return nil, errors.New("database error")
+	}
+
+	updates := map[string]interface{}{}
+
+	if input.Name != nil {
+		name := strings.ToLower(strings.TrimSpace(*input.Name))
+		if !reactionNamePattern.MatchString(name) {
+			return nil, errors.New("invalid reaction name")
+		}
+
+		var taken int64
+		err := database.DB.Model(&models.ReactionType{}).
+			Where("name = ? AND id <> ?", name, id).
+			Count(&taken).Error
+		if err != nil {
+			return nil, errors.New("database error")
+		}
+		if taken > 0 {
+			return nil, errors.New("reaction already exists")
+		}
+		updates["name"] = name
+	}
+	if input.Position != nil {
+		updates["position"] = *input.Position
+	}
+	if input.IsActive != nil {
+		updates["is_active"] = *input.IsActive
+	}
+
+	if len(updates) > 0 {
+		before := reactionType
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&reactionType).Updates(updates).Error; err != nil {
+				return errors.New("failed to update reaction")
+			}
+			// Reload so the response reflects what was stored
+			if err := tx.First(&reactionType, "id = ?", id).Error; err != nil {
+				return errors.New("database error")
+			}
+
+			return recordAudit(tx, AuditEntry{
+				ActorID:    input.ActorID,
+				Action:     "reaction_type.update",
+				TargetType: "reaction_type",
+				TargetID:   id,
+				Before:     before,
+				After:      reactionType,
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Order of reactions in the feeds follows the position
+	cache.Invalidate(cache.ReactionTypesKey)
+	cache.InvalidatePrefix(cache.FeedPrefix)
+
+	return &reactionType, nil
+}
+
+// DeleteReactionType removes a reaction from the set together with every use of it
+func (s *ReactionService) DeleteReactionType(id, actorID string) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var reactionType models.ReactionType
+		err := tx.First(&reactionType, "id = ?", id).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("reaction not found")
+			}
+			return errors.New("database error")
+		}
+
+		if err := tx.Where("emoji = ?", reactionType.Emoji).Delete(&models.Reaction{}).Error; err != nil {
+			return errors.New("failed to delete reactions")
+		}
+
+		if err := tx.Delete(&reactionType).Error; err != nil {
+			return errors.New("failed to delete reaction")
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    actorID,
+			Action:     "reaction_type.delete",
+			TargetType: "reaction_type",
+			TargetID:   id,
+			Before:     reactionType,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	cache.Invalidate(cache.ReactionTypesKey)
+	cache.InvalidatePrefix(cache.FeedPrefix)
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReadService handles what users have read
+// Threads are marked read as they are opened, see markThreadRead
+type ReadService struct{}
+
+// NewReadService creates a new instance of ReadService
+func NewReadService() *ReadService {
+	return &ReadService{}
+}
+
+// Requests on a thread less than this apart count as one visit
+// The post and its comments are fetched separately so both need to see the same "new since"
+const readVisitGap = 30 * time.Minute
+
+// MarkTopicRead marks every published post of a topic as read up to now for the user
+// Returns how many threads were marked
+func (s *ReadService) MarkTopicRead(userID, slug string) (int64, error) {
+	var topic models.Topic
+	if err := database.DB.Select("id").First(&topic, "slug = ?", slug).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("topic not found")
+		}
+		return 0, errors.New("failed to retrieve topic")
+	}
+
+	// Nothing is new on the next visit either so the previous position moves too
+	now := time.Now()
+	res := database.DB.Exec(`
+		INSERT INTO read_markers (id, user_id, post_id, last_read_at, previous_read_at, updated_at)
+		SELECT gen_random_uuid(), ?, posts.id, ?, ?, ? FROM posts
+		WHERE posts.topic_id = ? AND posts.status = ?
+		ON CONFLICT (user_id, post_id) DO UPDATE SET
+			last_read_at = EXCLUDED.last_read_at,
+			previous_read_at = EXCLUDED.previous_read_at,
+			updated_at = EXCLUDED.updated_at
+	`, userID, now, now, now, topic.ID, models.PostStatusPublished)
+	if res.Error != nil {
+		return 0, errors.New("failed to mark topic as read")
+	}
+
+	return res.RowsAffected, nil
+}
+
+// markThreadRead moves the users read position on a published post up to at
+// and returns where the current visit started, nil the first time the thread is opened
+// A single upsert so two requests of the same visit can not both start a new one
+func markThreadRead(userID, postID string, at time.Time) (*time.Time, error) {
+	var marker struct{ PreviousReadAt *time.Time }
+
+	err := database.DB.Raw(`
+		INSERT INTO read_markers (id, user_id, post_id, last_read_at, updated_at)
+		SELECT ?, ?, posts.id, ?, ? FROM posts
+		WHERE posts.id = ? AND posts.status = ?
+		ON CONFLICT (user_id, post_id) DO UPDATE SET
+			previous_read_at = CASE
+				WHEN read_markers.updated_at < ? THEN read_markers.last_read_at
+				ELSE read_markers.previous_read_at
+			END,
+			last_read_at = GREATEST(read_markers.last_read_at, EXCLUDED.last_read_at),
+			updated_at = EXCLUDED.updated_at
+		RETURNING previous_read_at
+	`, uuid.New().String(), userID, at, at, postID, models.PostStatusPublished, at.Add(-readVisitGap)).
+		Scan(&marker).Error
+	if err != nil {
+		return nil, errors.New("failed to update read position")
+	}
+
+	return marker.PreviousReadAt, nil
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Kk120306/cvwo-2026/backend/cache"
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/helpers"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevisionService handles the edit history of posts and comments
+type RevisionService struct{}
+
+// NewRevisionService creates a new instance of RevisionService
+func NewRevisionService() *RevisionService {
+	return &RevisionService{}
+}
+
+// RevisionDiff is the difference between two revisions
+// Title and Content are the newer version with <del> / <ins> marking what changed
+type RevisionDiff struct {
+	From         int     `json:"from"`
+	To           int     `json:"to"`
+	Title        *string `json:"title,omitempty"`
+	Content      string  `json:"content"`
+	ImageChanged bool    `json:"imageChanged"`
+	OldImageUrl  *string `json:"oldImageUrl,omitempty"`
+	NewImageUrl  *string `json:"newImageUrl,omitempty"`
+}
+
+// revisionSnapshot is the editable state of a post or comment
+type revisionSnapshot struct {
+	Title    *string
+	Content  string
+	ImageUrl *string
+	Format   string
+	Source   *string // markdown only
+}
+
+func (a revisionSnapshot) equal(b revisionSnapshot) bool {
+	return equalStringPtr(a.Title, b.Title) && a.Content == b.Content && equalStringPtr(a.ImageUrl, b.ImageUrl) &&
+		a.Format == b.Format && equalStringPtr(a.Source, b.Source)
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// editPost applies an edit to a post inside the callers transaction and records it as a revision
+// The post row is locked so concurrent edits get consecutive version numbers
+// An edit that does not change anything records no revision, neither do edits of drafts
+func editPost(tx *gorm.DB, postID, editorID string, next revisionSnapshot, restoredFrom *int) error {
+	var post models.Post
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, "id = ?", postID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("post not found")
+		}
+		return errors.New("database error")
+	}
+
+	current := revisionSnapshot{
+		Title:    &post.Title,
+		Content:  post.Content,
+		ImageUrl: post.ImageUrl,
+		Format:   post.Format,
+		Source:   post.Source,
+	}
+	if current.equal(next) {
+		return nil
+	}
+
+	// Update fields - Use map to allow nil values
+	updates := map[string]interface{}{
+		"title":     *next.Title,
+		"content":   next.Content,
+		"image_url": next.ImageUrl,
+		"format":    next.Format,
+		"source":    next.Source,
+		"link_url":  linkURL(next.Content),
+	}
+
+	// Nobody has seen a draft yet, so working on it is not an edit
+	if post.Status == models.PostStatusPublished {
+		original := models.Revision{EditorID: post.AuthorID, CreatedAt: post.CreatedAt}
+		if err := appendRevision(tx, "post", postID, current, original, next, editorID, restoredFrom); err != nil {
+			return err
+		}
+		updates["edited_at"] = time.Now()
+	}
+	if err := tx.Model(&post).Updates(updates).Error; err != nil {
+		return errors.New("failed to update post")
+	}
+
+	return nil
+}
+
+// editComment is editPost for comments, only the content can change
+func editComment(tx *gorm.DB, commentID, editorID string, next revisionSnapshot, restoredFrom *int) (*models.Comment, error) {
+	var comment models.Comment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&comment, "id = ?", commentID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("comment not found")
+		}
+		return nil, errors.New("database error")
+	}
+
+	current := revisionSnapshot{Content: comment.Content, Format: comment.Format, Source: comment.Source}
+	if current.equal(next) {
+		return &comment, nil
+	}
+
+	original := models.Revision{EditorID: comment.AuthorID, CreatedAt: comment.CreatedAt}
+	if err := appendRevision(tx, "comment", commentID, current, original, next, editorID, restoredFrom); err != nil {
+		return nil, err
+	}
+
+	editedAt := time.Now()
+	updates := map[string]interface{}{
+		"content":   next.Content,
+		"format":    next.Format,
+		"source":    next.Source,
+		"link_url":  linkURL(next.Content),
+		"edited_at": editedAt,
+	}
+	if err := tx.Model(&comment).Updates(updates).Error; err != nil {
+		return nil, errors.New("failed to update comment")
+	}
+	comment.Content = next.Content
+	comment.Format = next.Format
+	comment.Source = next.Source
+	comment.EditedAt = &editedAt
+
+	return &comment, nil
+}
+
+// appendRevision records the next version of a post or comment
+// Content from before revisions existed has no version 1 yet, so the current state is saved as
+// the original first, credited to the author at creation time
+func appendRevision(tx *gorm.DB, revisableType, revisableID string, current revisionSnapshot, original models.Revision,
+	next revisionSnapshot, editorID string, restoredFrom *int) error {
+	var latest int
+	err := tx.Model(&models.Revision{}).
+		Select("COALESCE(MAX(version), 0)").
+		Where("revisable_id = ? AND revisable_type = ?", revisableID, revisableType).
+		Scan(&latest).Error
+	if err != nil {
+		return errors.New("database error")
+	}
+
+	if latest == 0 {
+		original.RevisableID = revisableID
+		original.RevisableType = revisableType
+		original.Version = 1
+		original.Title = current.Title
+		original.Content = current.Content
+		original.ImageUrl = current.ImageUrl
+		original.Format = current.Format
+		original.Source = current.Source
+		if err := tx.Create(&original).Error; err != nil {
+			return errors.New("failed to record revision")
+		}
+		latest = 1
+	}
+
+	revision := models.Revision{
+		RevisableID:   revisableID,
+		RevisableType: revisableType,
+		Version:       latest + 1,
+		Title:         next.Title,
+		Content:       next.Content,
+		ImageUrl:      next.ImageUrl,
+		Format:        next.Format,
+		Source:        next.Source,
+		EditorID:      editorID,
+		RestoredFrom:  restoredFrom,
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return errors.New("failed to record revision")
+	}
+
+	return nil
+}
+
+// GetRevisions lists every revision of a post or comment, oldest first
+// Content that was never edited has no revisions and gets an empty list, viewer is nil for guests
+func (s *RevisionService) GetRevisions(revisableType, revisableID string, viewer *models.User) ([]models.Revision, error) {
+	if err := revisableExists(revisableType, revisableID, viewer); err != nil {
+		return nil, err
+	}
+
+	revisions := []models.Revision{}
+	err := database.DB.Preload("Editor").
+		Where("revisable_id = ? AND revisable_type = ?", revisableID, revisableType).
+		Order("version ASC").
+		Find(&revisions).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve revisions")
+	}
+
+	return revisions, nil
+}
+
+// DiffRevisions compares two versions of a post or comment, from may be newer than to
+func (s *RevisionService) DiffRevisions(revisableType, revisableID string, from, to int, viewer *models.User) (*RevisionDiff, error) {
+	if err := revisableExists(revisableType, revisableID, viewer); err != nil {
+		return nil, err
+	}
+
+	var revisions []models.Revision
+	err := database.DB.
+		Where("revisable_id = ? AND revisable_type = ? AND version IN ?", revisableID, revisableType, []int{from, to}).
+		Find(&revisions).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve revisions")
+	}
+
+	byVersion := map[int]models.Revision{}
+	for _, revision := range revisions {
+		byVersion[revision.Version] = revision
+	}
+	oldRevision, okOld := byVersion[from]
+	newRevision, okNew := byVersion[to]
+	if !okOld || !okNew {
+		return nil, errors.New("revision not found")
+	}
+
+	diff := RevisionDiff{
+		From:         from,
+		To:           to,
+		Content:      helpers.HTMLDiff(oldRevision.Content, newRevision.Content),
+		ImageChanged: !equalStringPtr(oldRevision.ImageUrl, newRevision.ImageUrl),
+		OldImageUrl:  oldRevision.ImageUrl,
+		NewImageUrl:  newRevision.ImageUrl,
+	}
+	if oldRevision.Title != nil && newRevision.Title != nil {
+		title := helpers.TextDiff(*oldRevision.Title, *newRevision.Title)
+		diff.Title = &title
+	}
+
+	return &diff, nil
+}
+
+// RollbackPost restores a post to an earlier revision - moderators only
+// The rollback is an edit itself so it becomes the newest revision and nothing is lost
+func (s *RevisionService) RollbackPost(postID string, version int, editorID, reason string) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		target, err := findRevision(tx, "post", postID, version)
+		if err != nil {
+			return err
+		}
+
+		var before models.Post
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, "id = ?", postID).Error; err != nil {
+			return errors.New("post not found")
+		}
+
+		err = editPost(tx, postID, editorID, revisionSnapshot{
+			Title:    target.Title,
+			Content:  target.Content,
+			ImageUrl: target.ImageUrl,
+			Format:   target.Format,
+			Source:   target.Source,
+		}, &version)
+		if err != nil {
+			return err
+		}
+
+		var after models.Post
+		if err := tx.First(&after, "id = ?", postID).Error; err != nil {
+			return errors.New("database error")
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    editorID,
+			Action:     "post.rollback",
+			TargetType: "post",
+			TargetID:   postID,
+			Before:     postSnapshot(&before),
+			After:      postSnapshot(&after),
+			Reason:     reason,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	cache.InvalidatePrefix(cache.FeedPrefix)
+
+	return nil
+}
+
+// RollbackComment restores a comment to an earlier revision - moderators only
+func (s *RevisionService) RollbackComment(commentID string, version int, editorID, reason string) (*models.Comment, error) {
+	var comment *models.Comment
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		target, err := findRevision(tx, "comment", commentID, version)
+		if err != nil {
+			return err
+		}
+
+		var before models.Comment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, "id = ?", commentID).Error; err != nil {
+			return errors.New("comment not found")
+		}
+
+		comment, err = editComment(tx, commentID, editorID, revisionSnapshot{
+			Content: target.Content,
+			Format:  target.Format,
+			Source:  target.Source,
+		}, &version)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    editorID,
+			Action:     "comment.rollback",
+			TargetType: "comment",
+			TargetID:   commentID,
+			Before:     commentSnapshot(&before),
+			After:      commentSnapshot(comment),
+			Reason:     reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// findRevision gets a single version of a post or comment
+func findRevision(tx *gorm.DB, revisableType, revisableID string, version int) (*models.Revision, error) {
+	var revision models.Revision
+	err := tx.Where("revisable_id = ? AND revisable_type = ? AND version = ?", revisableID, revisableType, version).
+		Take(&revision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("revision not found")
+		}
+		return nil, errors.New("database error")
+	}
+	return &revision, nil
+}
+
+// revisableExists checks the post / comment is there so a bad id is a 404 and not an empty history
+// Edits that got held are already in the history, so content that is not public is only shown to its author and admins
+func revisableExists(revisableType, revisableID string, viewer *models.User) error {
+	var target struct {
+		AuthorID string
+		Visible  bool
+	}
+	var res *gorm.DB
+	if revisableType == "post" {
+		res = database.DB.Model(&models.Post{}).
+			Select("author_id, status = ? AS visible", models.PostStatusPublished).
+			Where("id = ?", revisableID).
+			Scan(&target)
+	} else {
+		res = database.DB.Model(&models.Comment{}).
+			Joins("JOIN posts ON posts.id = comments.post_id").
+			Select("comments.author_id, (comments.is_held = ? AND posts.status = ?) AS visible", false, models.PostStatusPublished).
+			Where("comments.id = ?", revisableID).
+			Scan(&target)
+	}
+	if res.Error != nil {
+		return errors.New("database error")
+	}
+	if res.RowsAffected == 0 {
+		return errors.New(revisableType + " not found")
+	}
+	if !target.Visible && (viewer == nil || (viewer.ID != target.AuthorID && !viewer.IsAdmin)) {
+		return errors.New(revisableType + " not found")
+	}
+	return nil
+}
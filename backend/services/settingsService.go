@@ -0,0 +1,248 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Kk120306/cvwo-2026/backend/cache"
+	"github.com/Kk120306/cvwo-2026/backend/config"
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/helpers"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SettingsService handles users editing their own profile and privacy settings
+type SettingsService struct {
+	awsConfig config.AWSConfig
+}
+
+// NewSettingsService creates a new instance of SettingsService
+func NewSettingsService(awsConfig config.AWSConfig) *SettingsService {
+	return &SettingsService{
+		awsConfig: awsConfig,
+	}
+}
+
+// UserSettings is everything a user can change about their own account
+type UserSettings struct {
+	Username     string `json:"username"`
+	DisplayName  string `json:"displayName"`
+	Bio          string `json:"bio"`
+	AvatarURL    string `json:"avatarUrl"`
+	HideComments bool   `json:"hideComments"`
+	HideStats    bool   `json:"hideStats"`
+	// nil when the username can be changed right now
+	NextUsernameChange *time.Time `json:"nextUsernameChange"`
+}
+
+// UpdateSettingsInput represents a settings change, nil fields are left as they are
+// AvatarImage is the image name handed out by /images/s3Url, an empty one goes back to the default avatar
+type UpdateSettingsInput struct {
+	Username     *string
+	DisplayName  *string
+	Bio          *string
+	AvatarImage  *string
+	HideComments *bool
+	HideStats    *bool
+}
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 500
+	// how long a user has to wait between username changes, stops names being cycled through
+	usernameChangeCooldown = 30 * 24 * time.Hour
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,30}$`)
+
+// GetSettings returns the settings of the user
+func (s *SettingsService) GetSettings(userID string) (*UserSettings, error) {
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, errors.New("failed to retrieve settings")
+	}
+
+	return toUserSettings(&user), nil
+}
+
+// UpdateSettings applies a settings change for the user
+// Renames are recorded in the username history so the old profile link keeps working
+func (s *SettingsService) UpdateSettings(userID string, input UpdateSettingsInput) (*UserSettings, error) {
+	updates := map[string]interface{}{}
+
+	if input.DisplayName != nil {
+		displayName := strings.TrimSpace(*input.DisplayName)
+		if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+			return nil, errors.New("display name is too long")
+		}
+		if strings.ContainsAny(displayName, "\r\n\t") {
+			return nil, errors.New("display name must be a single line")
+		}
+		updates["display_name"] = displayName
+	}
+	if input.Bio != nil {
+		bio := strings.TrimSpace(*input.Bio)
+		if utf8.RuneCountInString(bio) > maxBioLength {
+			return nil, errors.New("bio is too long")
+		}
+		updates["bio"] = bio
+	}
+	if input.AvatarImage != nil {
+		if *input.AvatarImage == "" {
+			// back to the column default
+			updates["avatar_url"] = gorm.Expr("DEFAULT")
+		} else if helpers.IsImageName(*input.AvatarImage) {
+			// someone elses upload can not be claimed, it would be deleted on the next avatar change
+			owned, err := isUploadedBy(database.DB, *input.AvatarImage, userID)
+			if err != nil {
+				return nil, err
+			}
+			if !owned {
+				return nil, errors.New("invalid avatar image")
+			}
+			updates["avatar_url"] = helpers.ImageURL(s.awsConfig, *input.AvatarImage)
+		} else {
+			return nil, errors.New("invalid avatar image")
+		}
+	}
+	if input.HideComments != nil {
+		updates["hide_comments"] = *input.HideComments
+	}
+	if input.HideStats != nil {
+		updates["hide_stats"] = *input.HideStats
+	}
+
+	var newUsername string
+	if input.Username != nil {
+		newUsername = strings.TrimSpace(*input.Username)
+		if !usernamePattern.MatchString(newUsername) {
+			return nil, errors.New("usernames must be 3 to 30 letters, numbers or underscores")
+		}
+	}
+
+	var user models.User
+	var oldUsername, oldDisplayName, oldAvatar string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("user not found")
+			}
+			return errors.New("failed to retrieve settings")
+		}
+		oldUsername, oldDisplayName, oldAvatar = user.Username, user.DisplayName, user.AvatarURL
+
+		// Privacy toggles are fine while muted, anything other users get to read is not
+		if input.Username != nil || input.DisplayName != nil || input.Bio != nil || input.AvatarImage != nil {
+			if err := ensureCanPost(tx, userID); err != nil {
+				return err
+			}
+		}
+
+		if input.Username != nil && newUsername != user.Username {
+			if err := renameUser(tx, &user, newUsername); err != nil {
+				return err
+			}
+			updates["username"] = newUsername
+			updates["username_changed_at"] = time.Now()
+		}
+
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+			return errors.New("failed to update settings")
+		}
+
+		// re-read so the column default for a reset avatar comes back too
+		if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+			return errors.New("failed to retrieve settings")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// cached feeds and the topic list embed the authors name and avatar
+	if oldUsername != user.Username || oldDisplayName != user.DisplayName || oldAvatar != user.AvatarURL {
+		cache.InvalidatePrefix(cache.FeedPrefix)
+	}
+
+	// The old upload is unreachable now, cleaning it up is best effort
+	// Only the users own uploads go, an avatar from before uploads were recorded is left alone
+	if oldAvatar != user.AvatarURL {
+		if imageName, ok := helpers.UploadedImageName(s.awsConfig, oldAvatar); ok {
+			owned, err := isUploadedBy(database.DB, imageName, userID)
+			if err != nil {
+				log.Printf("failed to check old avatar %s: %v", imageName, err)
+			} else if owned {
+				if err := helpers.DeleteImage(s.awsConfig, imageName); err != nil {
+					log.Printf("failed to delete old avatar %s: %v", imageName, err)
+				} else if err := database.DB.Where("name = ?", imageName).Delete(&models.Upload{}).Error; err != nil {
+					log.Printf("failed to forget old avatar %s: %v", imageName, err)
+				}
+			}
+		}
+	}
+
+	return toUserSettings(&user), nil
+}
+
+// renameUser checks a username change is allowed and records it in the history
+func renameUser(tx *gorm.DB, user *models.User, newUsername string) error {
+	if user.UsernameChangedAt != nil && time.Since(*user.UsernameChangedAt) < usernameChangeCooldown {
+		return errors.New("you can only change your username once every 30 days")
+	}
+
+	// Serializes renames so two users can not both check and then take the same name
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('users.username'))").Error; err != nil {
+		return errors.New("failed to update settings")
+	}
+
+	// Case insensitive so nobody can pose as someone by changing a letters case
+	var taken int64
+	err := tx.Model(&models.User{}).
+		Where("LOWER(username) = LOWER(?) AND id <> ?", newUsername, user.ID).
+		Count(&taken).Error
+	if err != nil {
+		return errors.New("failed to update settings")
+	}
+	if taken > 0 {
+		return errors.New("username is already taken")
+	}
+
+	history := models.UsernameHistory{UserID: user.ID, OldUsername: user.Username, NewUsername: newUsername}
+	if err := tx.Create(&history).Error; err != nil {
+		return errors.New("failed to update settings")
+	}
+
+	return nil
+}
+
+func toUserSettings(user *models.User) *UserSettings {
+	settings := &UserSettings{
+		Username:     user.Username,
+		DisplayName:  user.DisplayName,
+		Bio:          user.Bio,
+		AvatarURL:    user.AvatarURL,
+		HideComments: user.HideComments,
+		HideStats:    user.HideStats,
+	}
+	if user.UsernameChangedAt != nil {
+		next := user.UsernameChangedAt.Add(usernameChangeCooldown)
+		if next.After(time.Now()) {
+			settings.NextUsernameChange = &next
+		}
+	}
+	return settings
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SubscriptionService handles following and muting topics and following users
+type SubscriptionService struct{}
+
+// NewSubscriptionService creates a new instance of SubscriptionService
+func NewSubscriptionService() *SubscriptionService {
+	return &SubscriptionService{}
+}
+
+// Subscriptions is everything a user follows or has muted
+type Subscriptions struct {
+	FollowedTopics []models.Topic `json:"followedTopics"`
+	MutedTopics    []models.Topic `json:"mutedTopics"`
+	FollowedUsers  []models.User  `json:"followedUsers"`
+}
+
+// SetTopicSubscription follows or mutes a topic for the user, an empty mode removes the subscription
+// Following a muted topic unmutes it and the other way round
+func (s *SubscriptionService) SetTopicSubscription(userID, slug, mode string) error {
+	if mode != "" && mode != models.SubscriptionFollow && mode != models.SubscriptionMute {
+		return errors.New("invalid subscription mode")
+	}
+
+	var topic models.Topic
+	if err := database.DB.Select("id").First(&topic, "slug = ?", slug).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("topic not found")
+		}
+		return errors.New("failed to retrieve topic")
+	}
+
+	if mode == "" {
+		err := database.DB.Where("user_id = ? AND topic_id = ?", userID, topic.ID).
+			Delete(&models.TopicSubscription{}).Error
+		if err != nil {
+			return errors.New("failed to update subscription")
+		}
+		return nil
+	}
+
+	// Upsert on unique_topic_subscription so switching between follow and mute is one statement
+	subscription := models.TopicSubscription{UserID: userID, TopicID: topic.ID, Mode: mode}
+	err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "topic_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"mode"}),
+	}).Create(&subscription).Error
+	if err != nil {
+		return errors.New("failed to update subscription")
+	}
+
+	return nil
+}
+
+// FollowUser makes followerID follow the user with the username, following twice is a no-op
+func (s *SubscriptionService) FollowUser(followerID, username string) error {
+	followee, err := findUserIDByUsername(username)
+	if err != nil {
+		return err
+	}
+	if followee == followerID {
+		return errors.New("you can not follow yourself")
+	}
+	blocked, err := isBlockedBy(database.DB, followerID, followee)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return errors.New("you can not follow this user")
+	}
+
+	follow := models.UserFollow{FollowerID: followerID, FolloweeID: followee}
+	err = database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error
+	if err != nil {
+		return errors.New("failed to follow user")
+	}
+
+	return nil
+}
+
+// UnfollowUser stops followerID following the user with the username
+func (s *SubscriptionService) UnfollowUser(followerID, username string) error {
+	followee, err := findUserIDByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	err = database.DB.Where("follower_id = ? AND followee_id = ?", followerID, followee).
+		Delete(&models.UserFollow{}).Error
+	if err != nil {
+		return errors.New("failed to unfollow user")
+	}
+
+	return nil
+}
+
+// GetSubscriptions lists the topics a user follows and has muted and the users they follow
+func (s *SubscriptionService) GetSubscriptions(userID string) (*Subscriptions, error) {
+	result := Subscriptions{
+		FollowedTopics: []models.Topic{},
+		MutedTopics:    []models.Topic{},
+		FollowedUsers:  []models.User{},
+	}
+
+	var topicSubs []models.TopicSubscription
+	err := database.DB.Preload("Topic").Where("user_id = ?", userID).Order("created_at ASC").Find(&topicSubs).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve subscriptions")
+	}
+	for _, sub := range topicSubs {
+		if sub.Mode == models.SubscriptionMute {
+			result.MutedTopics = append(result.MutedTopics, sub.Topic)
+		} else {
+			result.FollowedTopics = append(result.FollowedTopics, sub.Topic)
+		}
+	}
+
+	var follows []models.UserFollow
+	err = database.DB.Preload("Followee").Where("follower_id = ?", userID).Order("created_at ASC").Find(&follows).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve subscriptions")
+	}
+	for _, follow := range follows {
+		result.FollowedUsers = append(result.FollowedUsers, follow.Followee)
+	}
+
+	return &result, nil
+}
+
+func findUserIDByUsername(username string) (string, error) {
+	var user models.User
+	// the deleted user placeholder can not be followed
+	err := database.DB.Select("id").First(&user, "username = ? AND id <> ?", username, models.DeletedUserID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.New("user not found")
+		}
+		return "", errors.New("database error")
+	}
+	return user.ID, nil
+}
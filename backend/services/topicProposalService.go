@@ -0,0 +1,252 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Kk120306/cvwo-2026/backend/database"
+	"github.com/Kk120306/cvwo-2026/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TopicProposalService handles users proposing topics and admins deciding on them
+type TopicProposalService struct{}
+
+// NewTopicProposalService creates a new instance of TopicProposalService
+func NewTopicProposalService() *TopicProposalService {
+	return &TopicProposalService{}
+}
+
+// CreateProposalInput represents the data needed to propose a topic
+type CreateProposalInput struct {
+	Name        string
+	Description string
+	Reason      string
+	ProposerID  string
+}
+
+// ReviewProposalInput represents an admin approving or rejecting a proposal
+// The approved topic is created from the proposal, the other fields fill in what the proposer can not set
+type ReviewProposalInput struct {
+	ReviewerID string
+	Note       string // shown to the proposer, required when rejecting
+	Icon       string
+	Color      string
+	Rules      string
+	ParentSlug string
+}
+
+// how many proposals one user can have waiting at a time
+const maxPendingProposals = 3
+
+// CreateProposal submits a topic proposal for the admins to review
+func (s *TopicProposalService) CreateProposal(input CreateProposalInput) (*models.TopicProposal, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, errors.New("topic name cannot be empty")
+	}
+	if len(name) > 100 {
+		return nil, errors.New("topic name is too long")
+	}
+	if err := validateTopicMetadata(input.Description, "", "", ""); err != nil {
+		return nil, err
+	}
+
+	proposal := models.TopicProposal{
+		Name:        name,
+		Description: strings.TrimSpace(input.Description),
+		Reason:      strings.TrimSpace(input.Reason),
+		ProposerID:  input.ProposerID,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureCanPost(tx, input.ProposerID); err != nil {
+			return err
+		}
+
+		// Lock the proposers row so parallel submissions can not slip past the limit
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, "id = ?", input.ProposerID).Error; err != nil {
+			return errors.New("failed to create proposal")
+		}
+
+		var pending int64
+		err := tx.Model(&models.TopicProposal{}).
+			Where("proposer_id = ? AND status = ?", input.ProposerID, models.ProposalStatusPending).
+			Count(&pending).Error
+		if err != nil {
+			return errors.New("failed to create proposal")
+		}
+		if pending >= maxPendingProposals {
+			return errors.New("too many pending proposals")
+		}
+
+		var existing int64
+		if err := tx.Model(&models.Topic{}).Where("LOWER(name) = LOWER(?)", name).Count(&existing).Error; err != nil {
+			return errors.New("failed to create proposal")
+		}
+		if existing > 0 {
+			return errors.New("a topic with that name already exists")
+		}
+
+		err = tx.Model(&models.TopicProposal{}).
+			Where("LOWER(name) = LOWER(?) AND status = ?", name, models.ProposalStatusPending).
+			Count(&existing).Error
+		if err != nil {
+			return errors.New("failed to create proposal")
+		}
+		if existing > 0 {
+			return errors.New("that topic has already been proposed")
+		}
+
+		if err := tx.Create(&proposal).Error; err != nil {
+			return errors.New("failed to create proposal")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &proposal, nil
+}
+
+// GetProposals lists proposals with the given status, oldest first so the queue is worked in order
+// An empty status lists every proposal
+func (s *TopicProposalService) GetProposals(status string) ([]models.TopicProposal, error) {
+	proposals := []models.TopicProposal{}
+
+	query := database.DB.Preload("Proposer").Preload("Reviewer").Order("created_at ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Find(&proposals).Error; err != nil {
+		return nil, errors.New("failed to retrieve proposals")
+	}
+
+	return proposals, nil
+}
+
+// GetProposalsByUser lists the proposals a user has made, newest first
+func (s *TopicProposalService) GetProposalsByUser(userID string) ([]models.TopicProposal, error) {
+	proposals := []models.TopicProposal{}
+
+	err := database.DB.Where("proposer_id = ?", userID).Order("created_at DESC").Find(&proposals).Error
+	if err != nil {
+		return nil, errors.New("failed to retrieve proposals")
+	}
+
+	return proposals, nil
+}
+
+// ApproveProposal creates the proposed topic and marks the proposal approved, both or neither happen
+func (s *TopicProposalService) ApproveProposal(id string, input ReviewProposalInput) (*models.TopicProposal, *models.Topic, error) {
+	var proposal models.TopicProposal
+	var topic *models.Topic
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPendingProposal(tx, id, &proposal); err != nil {
+			return err
+		}
+
+		var err error
+		topic, err = createTopic(tx, CreateTopicInput{
+			Name:        proposal.Name,
+			Description: proposal.Description,
+			Icon:        input.Icon,
+			Color:       input.Color,
+			Rules:       input.Rules,
+			ParentSlug:  input.ParentSlug,
+			ActorID:     input.ReviewerID,
+			Reason:      "approved topic proposal " + proposal.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		proposal.TopicID = &topic.ID
+		return reviewProposal(tx, &proposal, models.ProposalStatusApproved, input)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	invalidateTopicCaches()
+
+	return &proposal, topic, nil
+}
+
+// RejectProposal marks a proposal rejected, the note tells the proposer why
+func (s *TopicProposalService) RejectProposal(id string, input ReviewProposalInput) (*models.TopicProposal, error) {
+	if strings.TrimSpace(input.Note) == "" {
+		return nil, errors.New("a note is required to reject a proposal")
+	}
+
+	var proposal models.TopicProposal
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPendingProposal(tx, id, &proposal); err != nil {
+			return err
+		}
+		return reviewProposal(tx, &proposal, models.ProposalStatusRejected, input)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &proposal, nil
+}
+
+// lockPendingProposal loads a proposal FOR UPDATE so two admins can not both decide on it
+func lockPendingProposal(tx *gorm.DB, id string, proposal *models.TopicProposal) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(proposal, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("proposal not found")
+		}
+		return errors.New("failed to retrieve proposal")
+	}
+	if proposal.Status != models.ProposalStatusPending {
+		return errors.New("proposal has already been reviewed")
+	}
+	return nil
+}
+
+func reviewProposal(tx *gorm.DB, proposal *models.TopicProposal, status string, input ReviewProposalInput) error {
+	now := time.Now()
+	proposal.Status = status
+	proposal.ReviewerID = &input.ReviewerID
+	proposal.ReviewNote = strings.TrimSpace(input.Note)
+	proposal.ReviewedAt = &now
+
+	err := tx.Model(&models.TopicProposal{}).Where("id = ?", proposal.ID).Updates(map[string]interface{}{
+		"status":      proposal.Status,
+		"reviewer_id": proposal.ReviewerID,
+		"review_note": proposal.ReviewNote,
+		"reviewed_at": proposal.ReviewedAt,
+		"topic_id":    proposal.TopicID,
+	}).Error
+	if err != nil {
+		return errors.New("failed to review proposal")
+	}
+
+	action := "topic_proposal.approve"
+	if status == models.ProposalStatusRejected {
+		action = "topic_proposal.reject"
+	}
+
+	return recordAudit(tx, AuditEntry{
+		ActorID:    input.ReviewerID,
+		Action:     action,
+		TargetType: "topic_proposal",
+		TargetID:   proposal.ID,
+		After: map[string]interface{}{
+			"name":     proposal.Name,
+			"status":   proposal.Status,
+			"topicId":  proposal.TopicID,
+			"proposer": proposal.ProposerID,
+		},
+		Reason: input.Note,
+	})
+}
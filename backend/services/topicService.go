@@ -2,11 +2,17 @@ package services
 
 import (
 	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/Kk120306/cvwo-2026/backend/cache"
 	"github.com/Kk120306/cvwo-2026/backend/database"
 	"github.com/Kk120306/cvwo-2026/backend/helpers"
 	"github.com/Kk120306/cvwo-2026/backend/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // TopicService handles topic business logic
@@ -19,49 +25,202 @@ func NewTopicService() *TopicService {
 
 // CreateTopicInput represents the data needed to create a topic
 type CreateTopicInput struct {
-	Name string
+	Name        string
+	Description string
+	Icon        string
+	Color       string
+	Rules       string // Markdown
+	ParentSlug  string // empty for a top level topic
+	ActorID     string // admin creating it, for the audit log
+	Reason      string
 }
 
 // UpdateTopicInput represents the data needed to update a topic
+// nil fields are left as they are, an empty ParentSlug moves the topic to the top level
 type UpdateTopicInput struct {
-	Name string
+	Name        string
+	Description *string
+	Icon        *string
+	Color       *string
+	Rules       *string
+	ParentSlug  *string
+	ActorID     string // admin making the change, for the audit log
+	Reason      string
 }
 
-// GetAllTopics retrieves all topics sorted by creation date
-func (s *TopicService) GetAllTopics() ([]models.Topic, error) {
+// TopicWithStats is a topic as listed on /topics, with its activity
+type TopicWithStats struct {
+	models.Topic
+	PostCount int64 `json:"postCount"`
+	// Newest published post or comment in the topic, nil when it has neither
+	LatestActivityAt *time.Time     `json:"latestActivityAt"`
+	LastPost         *TopicLastPost `gorm:"-" json:"lastPost"`
+
+	// Columns of the last post, folded into LastPost after the query
+	LastPostID          *string    `json:"-"`
+	LastPostTitle       string     `json:"-"`
+	LastPostPublishedAt *time.Time `json:"-"`
+	LastPostAuthor      string     `json:"-"`
+}
+
+// TopicLastPost is the most recently published post of a topic
+type TopicLastPost struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Author      string     `json:"author"` // username
+	PublishedAt *time.Time `json:"publishedAt"`
+}
+
+// limits on the topic metadata
+const (
+	maxTopicDescription = 500
+	maxTopicIcon        = 64
+	maxTopicRules       = 10000
+)
+
+var topicColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// GetAllTopics retrieves all topics in admin order with post counts and their latest activity
+// Everything comes out of one query, the lateral joins run once per topic and use idx_posts_topic_published
+func (s *TopicService) GetAllTopics() ([]TopicWithStats, error) {
 	// Create a slice for the topics
-	var topics []models.Topic
+	var topics []TopicWithStats
+
+	// Served from cache, dropped with the feeds whenever posts change
+	if cache.GetJSON(cache.TopicsKey, &topics) {
+		return topics, nil
+	}
 
-	// query all the topics from database ordered - appends it to topic slice
-	result := database.DB.Order("created_at desc").Find(&topics)
+	// Only published posts count, drafts would give away that something is coming
+	result := database.DB.Model(&models.Topic{}).
+		Select(`
+			topics.*,
+			COALESCE(post_stats.post_count, 0) AS post_count,
+			GREATEST(last_post.published_at, comment_stats.last_comment_at) AS latest_activity_at,
+			last_post.id AS last_post_id,
+			COALESCE(last_post.title, '') AS last_post_title,
+			last_post.published_at AS last_post_published_at,
+			COALESCE(last_author.username, '') AS last_post_author
+		`).
+		Joins(`
+			LEFT JOIN LATERAL (
+				SELECT COUNT(*) AS post_count FROM posts
+				WHERE posts.topic_id = topics.id AND posts.status = ?
+			) AS post_stats ON TRUE
+		`, models.PostStatusPublished).
+		Joins(`
+			LEFT JOIN LATERAL (
+				SELECT posts.id, posts.title, posts.published_at, posts.author_id FROM posts
+				WHERE posts.topic_id = topics.id AND posts.status = ?
+				ORDER BY posts.published_at DESC
+				LIMIT 1
+			) AS last_post ON TRUE
+		`, models.PostStatusPublished).
+		Joins("LEFT JOIN users AS last_author ON last_author.id = last_post.author_id").
+		Joins(`
+			LEFT JOIN LATERAL (
+				SELECT MAX(comments.created_at) AS last_comment_at FROM comments
+				JOIN posts ON posts.id = comments.post_id
+				WHERE posts.topic_id = topics.id AND posts.status = ? AND comments.is_held = false
+			) AS comment_stats ON TRUE
+		`, models.PostStatusPublished).
+		Order("topics.position ASC, topics.created_at DESC").
+		Find(&topics)
 
 	// If there was a error in retrieving from the database
 	if result.Error != nil {
 		return nil, errors.New("failed to retrieve topics")
 	}
 
+	for i := range topics {
+		t := &topics[i]
+		if t.LastPostID != nil {
+			t.LastPost = &TopicLastPost{
+				ID:          *t.LastPostID,
+				Title:       t.LastPostTitle,
+				Author:      t.LastPostAuthor,
+				PublishedAt: t.LastPostPublishedAt,
+			}
+		}
+	}
+
+	cache.SetJSON(cache.TopicsKey, topics)
+
 	return topics, nil
 }
 
-// CreateTopic creates a new topic with auto-generated slug
+// CreateTopic creates a new topic with auto-generated slug, recorded in the audit log against ActorID
+// New topics go to the end of the list
 func (s *TopicService) CreateTopic(input CreateTopicInput) (*models.Topic, error) {
+	var topic *models.Topic
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		topic, err = createTopic(tx, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateTopicCaches()
+
+	return topic, nil
+}
+
+// createTopic is CreateTopic inside the callers transaction, approving a proposal goes through here too
+func createTopic(tx *gorm.DB, input CreateTopicInput) (*models.Topic, error) {
 	// Validate input
+	input.Name = strings.TrimSpace(input.Name)
 	if input.Name == "" {
 		return nil, errors.New("topic name cannot be empty")
 	}
+	if err := validateTopicMetadata(input.Description, input.Icon, input.Color, input.Rules); err != nil {
+		return nil, err
+	}
 
 	topic := models.Topic{
-		Name: input.Name,
-		Slug: helpers.GenerateSlug(input.Name),
+		Name:        input.Name,
+		Description: strings.TrimSpace(input.Description),
+		Icon:        strings.TrimSpace(input.Icon),
+		Color:       input.Color,
+		Rules:       input.Rules,
 	}
 
-	// Creating the Topic
-	result := database.DB.Create(&topic)
+	parentID, err := resolveTopicParent(tx, "", input.ParentSlug)
+	if err != nil {
+		return nil, err
+	}
+	topic.ParentID = parentID
 
-	// if there was an error during creation
-	if result.Error != nil {
+	topic.Slug, err = allocateTopicSlug(tx, "", input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var last struct{ Position *int }
+	if err := tx.Model(&models.Topic{}).Select("MAX(position) AS position").Scan(&last).Error; err != nil {
 		return nil, errors.New("failed to create topic")
 	}
+	if last.Position != nil {
+		topic.Position = *last.Position + 1
+	}
+
+	// Creating the Topic
+	if err := tx.Create(&topic).Error; err != nil {
+		return nil, errors.New("failed to create topic")
+	}
+
+	err = recordAudit(tx, AuditEntry{
+		ActorID:    input.ActorID,
+		Action:     "topic.create",
+		TargetType: "topic",
+		TargetID:   topic.ID,
+		After:      topicSnapshot(&topic),
+		Reason:     input.Reason,
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return &topic, nil
 }
@@ -82,47 +241,280 @@ func (s *TopicService) FindTopicBySlug(slug string) (*models.Topic, error) {
 	return &topic, nil
 }
 
-// UpdateTopic updates a topic's name and regenerates slug
+// UpdateTopic updates a topic's name, regenerating the slug, and any metadata that was passed
 func (s *TopicService) UpdateTopic(topic *models.Topic, input UpdateTopicInput) error {
 	// Validate input
+	input.Name = strings.TrimSpace(input.Name)
 	if input.Name == "" {
 		return errors.New("topic name cannot be empty")
 	}
 
+	// Missing fields keep what the topic already has
+	description := stringOr(input.Description, topic.Description)
+	icon := stringOr(input.Icon, topic.Icon)
+	color := stringOr(input.Color, topic.Color)
+	rules := stringOr(input.Rules, topic.Rules)
+	if err := validateTopicMetadata(description, icon, color, rules); err != nil {
+		return err
+	}
+
 	// Updating the topic name and slug
-	newSlug := helpers.GenerateSlug(input.Name)
-	// Since topic is already found, it calls WHERE = loaded ID
-	res := database.DB.Model(topic).Updates(models.Topic{
-		Name: input.Name,
-		Slug: newSlug,
+	before := topicSnapshot(topic)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		newSlug, err := allocateTopicSlug(tx, topic.ID, input.Name)
+		if err != nil {
+			return err
+		}
+
+		parentID := topic.ParentID
+		if input.ParentSlug != nil {
+			parentID, err = resolveTopicParent(tx, topic.ID, *input.ParentSlug)
+			if err != nil {
+				return err
+			}
+		}
+
+		// A map so cleared fields are written too
+		res := tx.Model(&models.Topic{}).Where("id = ?", topic.ID).Updates(map[string]interface{}{
+			"name":        input.Name,
+			"slug":        newSlug,
+			"description": strings.TrimSpace(description),
+			"icon":        strings.TrimSpace(icon),
+			"color":       color,
+			"rules":       rules,
+			"parent_id":   parentID,
+		})
+
+		// If there was an error during update
+		if res.Error != nil {
+			return errors.New("failed to update topic")
+		}
+
+		// Update the topic object with new values
+		topic.Name = input.Name
+		topic.Slug = newSlug
+		topic.Description = strings.TrimSpace(description)
+		topic.Icon = strings.TrimSpace(icon)
+		topic.Color = color
+		topic.Rules = rules
+		topic.ParentID = parentID
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    input.ActorID,
+			Action:     "topic.update",
+			TargetType: "topic",
+			TargetID:   topic.ID,
+			Before:     before,
+			After:      topicSnapshot(topic),
+			Reason:     input.Reason,
+		})
 	})
+	if err != nil {
+		return err
+	}
+
+	invalidateTopicCaches()
 
-	// If there was an error during update
-	if res.Error != nil {
-		return errors.New("failed to update topic")
+	return nil
+}
+
+// ReorderTopics sets the position of topics to their order in slugs
+// Topics that are left out keep their position
+func (s *TopicService) ReorderTopics(slugs []string, actorID, reason string) ([]models.Topic, error) {
+	if len(slugs) == 0 {
+		return nil, errors.New("no topics to reorder")
 	}
 
-	// Update the topic object with new values
-	topic.Name = input.Name
-	topic.Slug = newSlug
+	positions := make(map[string]int, len(slugs))
+	for i, slug := range slugs {
+		if _, seen := positions[slug]; seen {
+			return nil, errors.New("topic listed more than once")
+		}
+		positions[slug] = i
+	}
+
+	var topics []models.Topic
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("slug IN ?", slugs).Find(&topics).Error
+		if err != nil {
+			return errors.New("failed to reorder topics")
+		}
+		if len(topics) != len(slugs) {
+			return errors.New("topic not found")
+		}
+
+		before := map[string]int{}
+		after := map[string]int{}
+		for i := range topics {
+			topic := &topics[i]
+			before[topic.Slug] = topic.Position
+			topic.Position = positions[topic.Slug]
+			after[topic.Slug] = topic.Position
+
+			if err := tx.Model(&models.Topic{}).Where("id = ?", topic.ID).Update("position", topic.Position).Error; err != nil {
+				return errors.New("failed to reorder topics")
+			}
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    actorID,
+			Action:     "topic.reorder",
+			TargetType: "topic",
+			Before:     before,
+			After:      after,
+			Reason:     reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateTopicCaches()
+
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Position < topics[j].Position })
+	return topics, nil
+}
+
+// DeleteTopic deletes a topic by slug, recorded in the audit log against actorID
+func (s *TopicService) DeleteTopic(slug, actorID, reason string) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var topic models.Topic
+		findErr := tx.First(&topic, "slug = ?", slug).Error
+		if findErr != nil {
+			if errors.Is(findErr, gorm.ErrRecordNotFound) {
+				return errors.New("topic not found")
+			}
+			return errors.New("failed to delete topic")
+		}
+
+		// Subtopics of a deleted category move up to the top level
+		if err := tx.Model(&models.Topic{}).Where("parent_id = ?", topic.ID).Update("parent_id", nil).Error; err != nil {
+			return errors.New("failed to delete topic")
+		}
+
+		// Delete through Gorm
+		// https://gorm.io/docs/delete.html
+		if err := tx.Delete(&topic).Error; err != nil {
+			return errors.New("failed to delete topic")
+		}
+
+		return recordAudit(tx, AuditEntry{
+			ActorID:    actorID,
+			Action:     "topic.delete",
+			TargetType: "topic",
+			TargetID:   topic.ID,
+			Before:     topicSnapshot(&topic),
+			Reason:     reason,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	invalidateTopicCaches()
 
 	return nil
 }
 
-// DeleteTopic deletes a topic by slug
-func (s *TopicService) DeleteTopic(slug string) error {
-	// Delete through Gorm - passing in the slug
-	// https://gorm.io/docs/delete.html
-	var topic models.Topic
-	result := database.DB.Delete(&topic, "slug = ?", slug)
-	if result.Error != nil {
-		return errors.New("failed to delete topic")
+// invalidateTopicCaches drops the topic list and the feeds, which embed each post's topic
+func invalidateTopicCaches() {
+	cache.Invalidate(cache.TopicsKey)
+	cache.InvalidatePrefix(cache.FeedPrefix)
+}
+
+// resolveTopicParent finds the id of the category a topic goes under, nil for the top level
+// topicID is empty for a topic that is being created
+func resolveTopicParent(tx *gorm.DB, topicID, parentSlug string) (*string, error) {
+	if parentSlug == "" {
+		return nil, nil
+	}
+
+	var parent models.Topic
+	if err := tx.First(&parent, "slug = ?", parentSlug).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("parent topic not found")
+		}
+		return nil, errors.New("failed to retrieve topic")
 	}
 
-	// When there were no changes to the database, means topic was not found
-	if result.RowsAffected == 0 {
-		return errors.New("topic not found")
+	if parent.ID == topicID {
+		return nil, errors.New("a topic can not be its own parent")
+	}
+	// Two levels only - the parent has to be top level and the topic can not have subtopics itself
+	if parent.ParentID != nil {
+		return nil, errors.New("topics can only be nested one level deep")
+	}
+	if topicID != "" {
+		var children int64
+		if err := tx.Model(&models.Topic{}).Where("parent_id = ?", topicID).Count(&children).Error; err != nil {
+			return nil, errors.New("failed to retrieve topic")
+		}
+		if children > 0 {
+			return nil, errors.New("topics can only be nested one level deep")
+		}
 	}
 
+	return &parent.ID, nil
+}
+
+func validateTopicMetadata(description, icon, color, rules string) error {
+	if len(description) > maxTopicDescription {
+		return errors.New("topic description is too long")
+	}
+	if len(icon) > maxTopicIcon {
+		return errors.New("topic icon is too long")
+	}
+	if color != "" && !topicColorPattern.MatchString(color) {
+		return errors.New("topic color must be a hex color like #1a2b3c")
+	}
+	if len(rules) > maxTopicRules {
+		return errors.New("topic rules are too long")
+	}
 	return nil
 }
+
+func stringOr(value *string, fallback string) string {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
+// allocateTopicSlug returns the slug for a topic called name, suffixed with -2, -3... when it is taken
+// topicID is the topic being renamed so its own slug does not count, empty when creating
+func allocateTopicSlug(tx *gorm.DB, topicID, name string) (string, error) {
+	base := helpers.GenerateSlug(name)
+	if base == "" {
+		return "", errors.New("topic name must contain letters or numbers")
+	}
+
+	// Serializes topic creation and renames so two of them can not pick the same suffix
+	// https://www.postgresql.org/docs/current/explicit-locking.html#ADVISORY-LOCKS
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('topics.slug'))").Error; err != nil {
+		return "", errors.New("failed to retrieve topic")
+	}
+
+	// Names are unique too, but unlike slugs a clash there is something the admin has to resolve
+	var sameName int64
+	err := tx.Model(&models.Topic{}).
+		Where("LOWER(name) = LOWER(?) AND id::text <> ?", name, topicID).
+		Count(&sameName).Error
+	if err != nil {
+		return "", errors.New("failed to retrieve topic")
+	}
+	if sameName > 0 {
+		return "", errors.New("a topic with that name already exists")
+	}
+
+	// _ is a LIKE wildcard and can appear in a slug
+	var taken []string
+	err = tx.Model(&models.Topic{}).
+		Where("(slug = ? OR slug LIKE ?) AND id::text <> ?", base, strings.ReplaceAll(base, "_", `\_`)+"-%", topicID).
+		Pluck("slug", &taken).Error
+	if err != nil {
+		return "", errors.New("failed to retrieve topic")
+	}
+
+	return helpers.UniqueSlug(base, taken), nil
+}
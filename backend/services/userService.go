@@ -17,18 +17,30 @@ func NewUserService() *UserService {
 }
 
 // UserProfile represents a user's profile with statistics
+// Counts are left out when the user hides them, the Hidden flags let the client say why
+// The posts and comments themselves are paged through /user/:username/posts and /comments
 type UserProfile struct {
 	models.User
-	PostCount    int64            `json:"postCount"`
-	CommentCount int64            `json:"commentCount"`
-	Posts        []models.Post    `json:"posts,omitempty"`
-	Comments     []models.Comment `json:"comments,omitempty"`
+	PostCount      *int64 `json:"postCount,omitempty"`
+	CommentCount   *int64 `json:"commentCount,omitempty"`
+	Karma          *Karma `json:"karma,omitempty"`
+	CommentsHidden bool   `json:"commentsHidden"`
+	StatsHidden    bool   `json:"statsHidden"`
+}
+
+// Karma is the score a user got on their posts and comments from other users
+type Karma struct {
+	Post    int64 `json:"post"`
+	Comment int64 `json:"comment"`
+	Total   int64 `json:"total"`
 }
 
 // FindUserByUsername finds a user by their username
+// The deleted user placeholder has no profile, its content is shown with the rest of each thread
 func (s *UserService) FindUserByUsername(username string) (*models.User, error) {
 	var user models.User
-	if err := database.DB.Where("username = ?", username).First(&user).Error; err != nil {
+	err := database.DB.Where("username = ? AND id <> ?", username, models.DeletedUserID).First(&user).Error
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("user not found")
 		}
@@ -38,87 +50,241 @@ func (s *UserService) FindUserByUsername(username string) (*models.User, error)
 	return &user, nil
 }
 
-// GetUserPostCount gets the count of posts authored by a user
+// GetUserPostCount gets the count of published posts authored by a user
 func (s *UserService) GetUserPostCount(userID string) int64 {
 	var count int64
-	database.DB.Model(&models.Post{}).Where("author_id = ?", userID).Count(&count)
+	database.DB.Model(&models.Post{}).Where("author_id = ? AND status = ?", userID, models.PostStatusPublished).Count(&count)
 	return count
 }
 
 // GetUserCommentCount gets the count of comments authored by a user
 func (s *UserService) GetUserCommentCount(userID string) int64 {
 	var count int64
-	database.DB.Model(&models.Comment{}).Where("author_id = ?", userID).Count(&count)
+	database.DB.Model(&models.Comment{}).Where("author_id = ? AND is_held = ?", userID, false).Count(&count)
 	return count
 }
 
-// GetUserPosts retrieves all posts authored by a user
-func (s *UserService) GetUserPosts(userID string) ([]models.Post, error) {
-	var posts []models.Post
-	err := database.DB.
-		Select("id", "title", "content", "topic_id", "author_id", "is_pinned", "created_at", "updated_at", "image_url").
-		Preload("Topic").
-		Preload("Author").
-		Where("author_id = ?", userID).
-		Order("created_at DESC").
-		Find(&posts).Error
+// UserPostsPage is one page of a users published posts
+// Total is left out when the user hides their stats, like the counts on their profile
+type UserPostsPage struct {
+	Posts []PostWithVotes `json:"posts"`
+	Total *int64          `json:"total,omitempty"`
+	Page  int             `json:"page"`
+	Limit int             `json:"limit"`
+}
 
+// UserCommentsPage is one page of a users comments
+type UserCommentsPage struct {
+	Comments []CommentWithVotes `json:"comments"`
+	Total    *int64             `json:"total,omitempty"`
+	Page     int                `json:"page"`
+	Limit    int                `json:"limit"`
+}
+
+// GetUserPosts returns a page of the published posts authored by a user with vote counts, drafts are listed through /posts/drafts
+// sort is new, old or top, the viewers own votes are filled in like the feeds
+func (s *UserService) GetUserPosts(username string, viewer *models.User, sort string, page, limit int) (*UserPostsPage, error) {
+	order, err := activityOrder("posts", "published_at", sort)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.FindUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	var viewerID *string
+	if viewer != nil {
+		viewerID = &viewer.ID
+	}
+
+	result := UserPostsPage{Posts: []PostWithVotes{}, Page: page, Limit: limit}
+	if !statsHiddenFrom(user, viewer) {
+		total := s.GetUserPostCount(user.ID)
+		result.Total = &total
+	}
+
+	err = postsWithVotesQuery(viewerID).
+		Where("posts.author_id = ? AND posts.status = ?", user.ID, models.PostStatusPublished).
+		Order(order).
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&result.Posts).Error
 	if err != nil {
 		return nil, errors.New("failed to retrieve user posts")
 	}
 
-	return posts, nil
+	if err := attachPostReactions(result.Posts, viewerID); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
 }
 
-// GetUserComments retrieves all comments authored by a user
-func (s *UserService) GetUserComments(userID string) ([]models.Comment, error) {
-	var comments []models.Comment
-	err := database.DB.
-		Select("id", "post_id", "author_id", "content", "created_at", "updated_at").
-		Where("author_id = ?", userID).
-		Preload("Author").
-		Order("created_at DESC").
-		Find(&comments).Error
+// GetUserComments returns a page of the comments authored by a user with vote counts and the post each is under
+// Users can hide their comment history, only they and admins see past that
+func (s *UserService) GetUserComments(username string, viewer *models.User, sort string, page, limit int) (*UserCommentsPage, error) {
+	order, err := activityOrder("comments", "created_at", sort)
+	if err != nil {
+		return nil, err
+	}
 
+	user, err := s.FindUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	var viewerID *string
+	if viewer != nil {
+		viewerID = &viewer.ID
+	}
+	if user.HideComments && (viewer == nil || (viewer.ID != user.ID && !viewer.IsAdmin)) {
+		return nil, errors.New("comments are hidden")
+	}
+
+	result := UserCommentsPage{Comments: []CommentWithVotes{}, Page: page, Limit: limit}
+	if !statsHiddenFrom(user, viewer) {
+		total := s.GetUserCommentCount(user.ID)
+		result.Total = &total
+	}
+
+	query := commentsWithVotesQuery(viewerID).
+		Where("comments.author_id = ?", user.ID)
+	// held comments are only listed for their author
+	if viewer == nil || viewer.ID != user.ID {
+		query = query.Where("comments.is_held = ?", false)
+	}
+
+	err = query.
+		Preload("Post", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "title", "topic_id", "author_id", "status", "published_at")
+		}).
+		Preload("Post.Topic").
+		Order(order).
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&result.Comments).Error
 	if err != nil {
 		return nil, errors.New("failed to retrieve user comments")
 	}
 
-	return comments, nil
+	if err := attachCommentReactions(result.Comments, viewerID); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetUserKarma adds up the score of everything a user wrote
+// Their own votes on their own posts and comments do not count
+func (s *UserService) GetUserKarma(userID string) (*Karma, error) {
+	var totals struct {
+		PostScore        int64
+		CommentScore     int64
+		SelfPostVotes    int64
+		SelfCommentVotes int64
+	}
+
+	// Scores are the stored counters, the self votes are few and found through unique_vote which leads with user_id
+	err := database.DB.Raw(`
+		SELECT
+			(SELECT COALESCE(SUM(score), 0) FROM posts WHERE author_id = @user AND status = @published) AS post_score,
+			(SELECT COALESCE(SUM(score), 0) FROM comments WHERE author_id = @user) AS comment_score,
+			(SELECT COALESCE(SUM(CASE WHEN votes.vote_type = 'like' THEN 1 ELSE -1 END), 0)
+				FROM votes JOIN posts ON posts.id = votes.votable_id
+				WHERE votes.user_id = @user AND votes.votable_type = 'post'
+				AND posts.author_id = @user AND posts.status = @published) AS self_post_votes,
+			(SELECT COALESCE(SUM(CASE WHEN votes.vote_type = 'like' THEN 1 ELSE -1 END), 0)
+				FROM votes JOIN comments ON comments.id = votes.votable_id
+				WHERE votes.user_id = @user AND votes.votable_type = 'comment'
+				AND comments.author_id = @user) AS self_comment_votes
+	`, map[string]interface{}{"user": userID, "published": models.PostStatusPublished}).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, errors.New("failed to calculate karma")
+	}
+
+	karma := Karma{
+		Post:    totals.PostScore - totals.SelfPostVotes,
+		Comment: totals.CommentScore - totals.SelfCommentVotes,
+	}
+	karma.Total = karma.Post + karma.Comment
+	return &karma, nil
+}
+
+// activityOrder is the ORDER BY for a profile listing, ties are broken on id so pages do not overlap
+func activityOrder(table, timeColumn, sort string) (string, error) {
+	switch sort {
+	case "", "new":
+		return table + "." + timeColumn + " DESC, " + table + ".id DESC", nil
+	case "old":
+		return table + "." + timeColumn + " ASC, " + table + ".id ASC", nil
+	case "top":
+		return table + ".score DESC, " + table + "." + timeColumn + " DESC, " + table + ".id DESC", nil
+	}
+	return "", errors.New("invalid sort")
+}
+
+// FindRenamedUser returns the current username of whoever last went by username
+// Used to redirect old profile links, a live user with that name always wins over the history
+func (s *UserService) FindRenamedUser(username string) (string, error) {
+	var current string
+	err := database.DB.Model(&models.UsernameHistory{}).
+		Select("users.username").
+		Joins("JOIN users ON users.id = username_histories.user_id").
+		Where("username_histories.old_username = ?", username).
+		Order("username_histories.created_at DESC").
+		Limit(1).
+		Scan(&current).Error
+	if err != nil {
+		return "", errors.New("failed to retrieve user")
+	}
+	if current == "" {
+		return "", errors.New("user not found")
+	}
+
+	return current, nil
 }
 
-// GetUserProfile builds a complete user profile with optional posts and comments
-func (s *UserService) GetUserProfile(username string, includePosts bool, includeComments bool) (*UserProfile, error) {
+// GetUserProfile builds a user profile with their statistics
+// viewer is nil for anonymous requests, the owner and admins see past the privacy settings
+func (s *UserService) GetUserProfile(username string, viewer *models.User) (*UserProfile, error) {
 	// Find user by username
 	user, err := s.FindUserByUsername(username)
 	if err != nil {
 		return nil, err
 	}
 
+	private := viewer == nil || (viewer.ID != user.ID && !viewer.IsAdmin)
+
 	// Build profile with counts
 	profile := &UserProfile{
-		User:         *user,
-		PostCount:    s.GetUserPostCount(user.ID),
-		CommentCount: s.GetUserCommentCount(user.ID),
+		User:           *user,
+		CommentsHidden: private && user.HideComments,
+		StatsHidden:    statsHiddenFrom(user, viewer),
+	}
+	if profile.StatsHidden {
+		return profile, nil
 	}
 
-	// Optionally include posts
-	if includePosts {
-		posts, err := s.GetUserPosts(user.ID)
-		if err != nil {
-			return nil, err
-		}
-		profile.Posts = posts
+	postCount := s.GetUserPostCount(user.ID)
+	profile.PostCount = &postCount
+	if !profile.CommentsHidden {
+		commentCount := s.GetUserCommentCount(user.ID)
+		profile.CommentCount = &commentCount
 	}
 
-	// Optionally include comments
-	if includeComments {
-		comments, err := s.GetUserComments(user.ID)
-		if err != nil {
-			return nil, err
-		}
-		profile.Comments = comments
+	karma, err := s.GetUserKarma(user.ID)
+	if err != nil {
+		return nil, err
 	}
+	profile.Karma = karma
 
 	return profile, nil
 }
+
+// statsHiddenFrom reports whether the viewer is kept from the users counts, only the user and admins see past HideStats
+func statsHiddenFrom(user *models.User, viewer *models.User) bool {
+	return user.HideStats && (viewer == nil || (viewer.ID != user.ID && !viewer.IsAdmin))
+}
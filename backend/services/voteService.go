@@ -1,11 +1,16 @@
 package services
 
 import (
+	"encoding/json"
 	"errors"
+	"time"
 
+	"github.com/Kk120306/cvwo-2026/backend/cache"
 	"github.com/Kk120306/cvwo-2026/backend/database"
 	"github.com/Kk120306/cvwo-2026/backend/models"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // VoteService handles vote business logic
@@ -30,80 +35,126 @@ type VoteCounts struct {
 	MyVote   *string `json:"myVote"`
 }
 
-// FindExistingVote finds a user's existing vote on a votable item
-func (s *VoteService) FindExistingVote(userID, votableID, votableType string) (*models.Vote, error) {
-	var vote models.Vote
-	err := database.DB.Where("user_id = ? AND votable_id = ? AND votable_type = ?",
-		userID, votableID, votableType).First(&vote).Error
+// ToggleVote applies a like / dislike click as one transaction and returns the new counts
+// No vote yet -> create it, same vote again -> remove it, other vote -> switch it
+// The target row is locked first which both checks it exists and serializes concurrent clicks
+// so a double click can never race into the unique_vote index
+// idempotencyKey is optional - a retried request with the same key gets the first result back
+func (s *VoteService) ToggleVote(userID string, input VoteInput, idempotencyKey string) (*VoteCounts, error) {
+	var result VoteCounts
+	replayed := false
 
-	// checking for any errors
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) { // Use error package cus error can be wrapped
-			return nil, nil // No vote exists (not an error)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureCanInteract(tx, userID); err != nil {
+			return err
 		}
-		return nil, errors.New("database error")
-	}
 
-	return &vote, nil
-}
+		// Claim the idempotency key before doing anything
+		if idempotencyKey != "" {
+			stored, err := claimIdempotencyKey(tx, userID, idempotencyKey, input)
+			if err != nil {
+				return err
+			}
+			if stored != nil {
+				result = *stored
+				replayed = true
+				return nil
+			}
+		}
 
-// CreateVote creates a new vote
-func (s *VoteService) CreateVote(userID string, input VoteInput) error {
+		// Lock the post / comment - SELECT ... FOR UPDATE
+		// https://www.postgresql.org/docs/current/explicit-locking.html#LOCKING-ROWS
+		// Only published posts and the visible comments under them can be voted on, anything else is not found
+		lockQuery := "SELECT id FROM posts WHERE id = ? AND status = ? FOR UPDATE"
+		if input.VotableType == "comment" {
+			lockQuery = `SELECT comments.id FROM comments
+				JOIN posts ON posts.id = comments.post_id
+				WHERE comments.id = ? AND posts.status = ? AND comments.is_held = false
+				FOR UPDATE OF comments`
+		}
+		var lockedID string
+		lockErr := tx.Raw(lockQuery, input.VotableID, models.PostStatusPublished).
+			Scan(&lockedID).Error
+		if lockErr != nil {
+			return errors.New("database error")
+		}
+		if lockedID == "" {
+			return errors.New(input.VotableType + " not found")
+		}
 
-	switch input.VotableType {
-	case "post":
-		var post models.Post
-		err := database.DB.First(&post, "id = ?", input.VotableID).Error
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("post not found")
-			}
-			return errors.New("database error checking post")
+		// Locked threads and archived topics take no more votes
+		if err := ensureThreadOpen(tx, input.VotableType, input.VotableID); err != nil {
+			return err
 		}
-	case "comment":
-		var comment models.Comment
-		err := database.DB.First(&comment, "id = ?", input.VotableID).Error
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("comment not found")
-			}
-			return errors.New("database error checking comment")
+
+		// Existing vote of this user, if any
+		var existing models.Vote
+		findErr := tx.Where("user_id = ? AND votable_id = ? AND votable_type = ?",
+			userID, input.VotableID, input.VotableType).Take(&existing).Error
+		if findErr != nil && !errors.Is(findErr, gorm.ErrRecordNotFound) {
+			return errors.New("database error")
 		}
-	}
 
-	newVote := models.Vote{
-		UserID:      userID,
-		VotableID:   input.VotableID,
-		VotableType: input.VotableType,
-		VoteType:    input.VoteType,
-	}
+		var delta counterDelta
+		var myVote *string
 
-	// Checking if there is a error creating the new vote
-	createErr := database.DB.Create(&newVote).Error
-	if createErr != nil {
-		return errors.New("failed to create vote")
-	}
+		switch {
+		case errors.Is(findErr, gorm.ErrRecordNotFound):
+			// No vote exists, create one
+			newVote := models.Vote{
+				UserID:      userID,
+				VotableID:   input.VotableID,
+				VotableType: input.VotableType,
+				VoteType:    input.VoteType,
+			}
+			if err := tx.Create(&newVote).Error; err != nil {
+				return errors.New("failed to create vote")
+			}
+			delta = voteDelta(input.VoteType, 1)
+			myVote = &input.VoteType
+		case existing.VoteType == input.VoteType:
+			// Same vote clicked, remove it
+			if err := tx.Delete(&existing).Error; err != nil {
+				return errors.New("failed to remove vote")
+			}
+			delta = voteDelta(existing.VoteType, -1)
+		default:
+			// Different vote, switch it
+			delta = voteDelta(existing.VoteType, -1).add(voteDelta(input.VoteType, 1))
+			if err := tx.Model(&existing).Update("vote_type", input.VoteType).Error; err != nil {
+				return errors.New("failed to update vote")
+			}
+			myVote = &input.VoteType
+		}
 
-	return nil
-}
+		if err := applyCounterDelta(tx, input.VotableType, input.VotableID, delta); err != nil {
+			return errors.New("failed to update vote counts")
+		}
+
+		// Read the counters back while the row is still locked
+		countErr := tx.Table(votableTable(input.VotableType)).
+			Select("like_count AS likes, dislike_count AS dislikes").
+			Where("id = ?", input.VotableID).
+			Scan(&result).Error
+		if countErr != nil {
+			return errors.New("failed to get vote counts")
+		}
+		result.MyVote = myVote
 
-// DeleteVote deletes an existing vote (when user clicks same vote again)
-func (s *VoteService) DeleteVote(vote *models.Vote) error {
-	delErr := database.DB.Delete(vote).Error
-	if delErr != nil {
-		return errors.New("failed to remove vote")
+		if idempotencyKey != "" {
+			return storeIdempotentResult(tx, userID, idempotencyKey, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
-}
 
-// UpdateVote updates an existing vote (when user clicks different vote)
-func (s *VoteService) UpdateVote(vote *models.Vote, newVoteType string) error {
-	vote.VoteType = newVoteType
-	saveErr := database.DB.Save(vote).Error
-	if saveErr != nil {
-		return errors.New("failed to update vote")
+	if !replayed {
+		invalidateVoteCaches(input.VotableType, input.VotableID)
 	}
-	return nil
+
+	return &result, nil
 }
 
 // GetVoteCountsWithUserVote gets vote counts and user's vote using a single query
@@ -111,17 +162,14 @@ func (s *VoteService) GetVoteCountsWithUserVote(votableID, votableType, userID s
 	// where the result is stored
 	var result VoteCounts
 
-	// Database query
-	err := database.DB.Model(&models.Vote{}).
-		// sum case adds up likes and dislikes when vote_type is met and stored as likes and dislikes
-		// Coalesce helps to convert any null rows to 0
-		// For each row keep only if user id matches, else null and then max just removes all nulls
+	// Counts come from the counters on the post / comment, the users vote from the votes table
+	err := database.DB.Table(votableTable(votableType)).
 		Select(`
-			COALESCE(SUM(CASE WHEN vote_type = 'like' THEN 1 ELSE 0 END),0) AS likes,
-			COALESCE(SUM(CASE WHEN vote_type = 'dislike' THEN 1 ELSE 0 END),0) AS dislikes,
-			MAX(CASE WHEN user_id = ? THEN vote_type ELSE NULL END) AS my_vote
-		`, userID).
-		Where("votable_id = ? AND votable_type = ?", votableID, votableType).
+			like_count AS likes,
+			dislike_count AS dislikes,
+			(SELECT vote_type FROM votes WHERE votable_id = ? AND votable_type = ? AND user_id = ?) AS my_vote
+		`, votableID, votableType, userID).
+		Where("id = ?", votableID).
 		Scan(&result).Error
 
 	if err != nil {
@@ -133,25 +181,30 @@ func (s *VoteService) GetVoteCountsWithUserVote(votableID, votableType, userID s
 
 // GetVoteCounts gets vote counts without user's vote
 func (s *VoteService) GetVoteCounts(votableID, votableType string) (int64, int64, error) {
-	// variables to hold the amount of likes and dislikes
-	var likes int64
-	var dislikes int64
-
-	// Count likes from the database
-	likeQuery := database.DB.Model(&models.Vote{}).Where("votable_id = ? AND votable_type = ? AND vote_type = ?",
-		votableID, votableType, "like").Count(&likes)
-	if likeQuery.Error != nil {
-		return 0, 0, errors.New("database error")
+	// where the result is stored
+	var counts VoteCounts
+
+	key := cache.VoteCountKey(votableType, votableID)
+	if cache.GetJSON(key, &counts) {
+		return counts.Likes, counts.Dislikes, nil
 	}
 
-	// Count dislikes in the database
-	dislikeQuery := database.DB.Model(&models.Vote{}).Where("votable_id = ? AND votable_type = ? AND vote_type = ?",
-		votableID, votableType, "dislike").Count(&dislikes)
-	if dislikeQuery.Error != nil {
+	// Read the stored counters of the post / comment
+	// a missing one is not cached, ids that never existed would otherwise fill the cache with zeros
+	res := database.DB.Table(votableTable(votableType)).
+		Select("like_count AS likes, dislike_count AS dislikes").
+		Where("id = ?", votableID).
+		Scan(&counts)
+	if res.Error != nil {
 		return 0, 0, errors.New("database error")
 	}
+	if res.RowsAffected == 0 {
+		return 0, 0, errors.New(votableType + " not found")
+	}
+
+	cache.SetJSON(key, counts)
 
-	return likes, dislikes, nil
+	return counts.Likes, counts.Dislikes, nil
 }
 
 // ValidateVoteInput validates vote input data
@@ -161,6 +214,11 @@ func (s *VoteService) ValidateVoteInput(input VoteInput) error {
 		return errors.New("invalid content type")
 	}
 
+	// Validate the id before it reaches a uuid column
+	if _, err := uuid.Parse(input.VotableID); err != nil {
+		return errors.New("invalid content id")
+	}
+
 	// Validate vote type
 	if input.VoteType != "like" && input.VoteType != "dislike" {
 		return errors.New("invalid vote type")
@@ -168,3 +226,188 @@ func (s *VoteService) ValidateVoteInput(input VoteInput) error {
 
 	return nil
 }
+
+// invalidateVoteCaches drops the cached counts of a votable item
+// Post votes also show in the feeds so those go too
+func invalidateVoteCaches(votableType, votableID string) {
+	cache.Invalidate(cache.VoteCountKey(votableType, votableID))
+	if votableType == "post" {
+		cache.InvalidatePrefix(cache.FeedPrefix)
+	}
+}
+
+// counterDelta is how much a vote change moves the counters of a post or comment
+type counterDelta struct {
+	likes    int64
+	dislikes int64
+}
+
+// voteDelta returns the delta of adding (sign 1) or removing (sign -1) a vote of voteType
+func voteDelta(voteType string, sign int64) counterDelta {
+	if voteType == "like" {
+		return counterDelta{likes: sign}
+	}
+	return counterDelta{dislikes: sign}
+}
+
+// add combines two deltas
+func (d counterDelta) add(other counterDelta) counterDelta {
+	return counterDelta{likes: d.likes + other.likes, dislikes: d.dislikes + other.dislikes}
+}
+
+// votableTable maps a votable type to the table holding its counters
+func votableTable(votableType string) string {
+	if votableType == "comment" {
+		return "comments"
+	}
+	return "posts"
+}
+
+// applyCounterDelta moves the counters of a post or comment inside the callers transaction
+// Uses UpdateColumns so a vote does not touch updated_at
+func applyCounterDelta(tx *gorm.DB, votableType, votableID string, delta counterDelta) error {
+	result := tx.Table(votableTable(votableType)).
+		Where("id = ?", votableID).
+		UpdateColumns(map[string]interface{}{
+			"like_count":    gorm.Expr("like_count + ?", delta.likes),
+			"dislike_count": gorm.Expr("dislike_count + ?", delta.dislikes),
+			"score":         gorm.Expr("score + ?", delta.likes-delta.dislikes),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// The post / comment was deleted in the meantime, roll the vote back too
+	if result.RowsAffected == 0 {
+		return errors.New("votable not found")
+	}
+
+	return nil
+}
+
+// CounterDrift is a post or comment whose stored counters do not match the votes table
+type CounterDrift struct {
+	VotableType    string `json:"votableType"`
+	VotableID      string `json:"votableId"`
+	StoredLikes    int64  `json:"storedLikes"`
+	StoredDislikes int64  `json:"storedDislikes"`
+	StoredScore    int64  `json:"storedScore"`
+	ActualLikes    int64  `json:"actualLikes"`
+	ActualDislikes int64  `json:"actualDislikes"`
+}
+
+// ReconcileCounters compares the stored counters against the votes table and reports every drift
+// Unless dryRun is set the drifted rows are recounted
+func (s *VoteService) ReconcileCounters(dryRun bool) ([]CounterDrift, error) {
+	var drifts []CounterDrift
+
+	for _, votableType := range []string{"post", "comment"} {
+		var found []CounterDrift
+		err := database.DB.Raw(`
+			SELECT t.id AS votable_id,
+				t.like_count AS stored_likes, t.dislike_count AS stored_dislikes, t.score AS stored_score,
+				COALESCE(v.likes, 0) AS actual_likes, COALESCE(v.dislikes, 0) AS actual_dislikes
+			FROM `+votableTable(votableType)+` AS t
+			LEFT JOIN (
+				SELECT votable_id,
+					COUNT(*) FILTER (WHERE vote_type = 'like') AS likes,
+					COUNT(*) FILTER (WHERE vote_type = 'dislike') AS dislikes
+				FROM votes
+				WHERE votable_type = ?
+				GROUP BY votable_id
+			) AS v ON v.votable_id = t.id
+			WHERE t.like_count <> COALESCE(v.likes, 0)
+				OR t.dislike_count <> COALESCE(v.dislikes, 0)
+				OR t.score <> COALESCE(v.likes, 0) - COALESCE(v.dislikes, 0)
+		`, votableType).Scan(&found).Error
+		if err != nil {
+			return nil, errors.New("failed to compare vote counters")
+		}
+		for i := range found {
+			found[i].VotableType = votableType
+		}
+
+		if !dryRun && len(found) > 0 {
+			ids := make([]string, len(found))
+			for i, drift := range found {
+				ids[i] = drift.VotableID
+			}
+
+			if err := database.RecountVoteCounters(database.DB, votableType, ids); err != nil {
+				return nil, errors.New("failed to fix vote counters")
+			}
+			for _, id := range ids {
+				invalidateVoteCaches(votableType, id)
+			}
+		}
+
+		drifts = append(drifts, found...)
+	}
+
+	return drifts, nil
+}
+
+// how long an idempotency key is remembered for
+const idempotencyKeyTTL = 24 * time.Hour
+
+// claimIdempotencyKey records the key for this request inside the vote transaction
+// Returns the stored result when the key was already used for the same request
+func claimIdempotencyKey(tx *gorm.DB, userID, key string, input VoteInput) (*VoteCounts, error) {
+	fingerprint := input.VotableType + ":" + input.VotableID + ":" + input.VoteType
+
+	// Forget this users expired keys so the table does not keep growing
+	cleanupErr := tx.Where("user_id = ? AND created_at < ?", userID, time.Now().Add(-idempotencyKeyTTL)).
+		Delete(&models.IdempotencyKey{}).Error
+	if cleanupErr != nil {
+		return nil, errors.New("database error")
+	}
+
+	// ON CONFLICT DO NOTHING - a concurrent request with the same key waits here until the first one commits
+	// https://gorm.io/docs/create.html#Upsert-On-Conflict
+	claim := models.IdempotencyKey{
+		UserID:  userID,
+		Key:     key,
+		Request: fingerprint,
+	}
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&claim)
+	if res.Error != nil {
+		return nil, errors.New("database error")
+	}
+	if res.RowsAffected == 1 {
+		return nil, nil // first time this key is seen
+	}
+
+	// Key was used before - replay its result
+	var stored models.IdempotencyKey
+	err := tx.Where("user_id = ? AND key = ?", userID, key).Take(&stored).Error
+	if err != nil {
+		return nil, errors.New("database error")
+	}
+	if stored.Request != fingerprint {
+		return nil, errors.New("idempotency key was already used for a different request")
+	}
+
+	var result VoteCounts
+	if err := json.Unmarshal([]byte(stored.Response), &result); err != nil {
+		return nil, errors.New("database error")
+	}
+
+	return &result, nil
+}
+
+// storeIdempotentResult saves the result against the key so retries can replay it
+func storeIdempotentResult(tx *gorm.DB, userID, key string, result VoteCounts) error {
+	response, err := json.Marshal(result)
+	if err != nil {
+		return errors.New("failed to store vote result")
+	}
+
+	err = tx.Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND key = ?", userID, key).
+		Update("response", string(response)).Error
+	if err != nil {
+		return errors.New("failed to store vote result")
+	}
+
+	return nil
+}
@@ -47,4 +47,29 @@ export const createTopic = async (name: string) => {
     const data = await res.json();
     toast.success("Topic created successfully");
     return data.topic;
-};
\ No newline at end of file
+};
+
+// Function to propose a new topic, only admins can create topics so everyone else suggests them
+export const proposeTopic = async (name: string, reason: string) => {
+    const endPoint = `${baseUrl}/topics/proposals`;
+
+    const res = await fetch(endPoint, {
+        method: "POST",
+        headers: {
+            "Content-Type": "application/json",
+        },
+        credentials: "include",
+        body: JSON.stringify({ name, reason }),
+    });
+
+    if (!res.ok) {
+        const err = await res.json();
+        toast.error(err.error || "Failed to propose topic");
+        throw new Error(err.error || "Failed to propose topic");
+    }
+
+    // Parse the JSON response
+    const data = await res.json();
+    toast.success("Topic proposed, an admin will review it");
+    return data.proposal;
+};
@@ -1,14 +1,9 @@
-
-import type { UserProfile } from "../types/globalTypes";
+import type { Comment, Post, UserProfile } from "../types/globalTypes";
 
 const baseUrl = '/api';
 
-export async function getUserProfile(username: string, includePosts = false, includeComments = false) {
-    const params = new URLSearchParams();
-    if (includePosts) params.append('posts', 'true');
-    if (includeComments) params.append('comments', 'true');
-
-    const url = `${baseUrl}/user/profile/${username}${params.toString() ? '?' + params : ''}`;
+export async function getUserProfile(username: string) {
+    const url = `${baseUrl}/user/profile/${username}`;
 
     const response = await fetch(url, {
         method: "GET",
@@ -19,6 +14,39 @@ export async function getUserProfile(username: string, includePosts = false, inc
     if (!response.ok) throw new Error("Failed to load user");
 
     const data = await response.json();
-    console.log(data);
     return data.user as UserProfile;
-}
\ No newline at end of file
+}
+
+// Profile activity is paged - sort is new, old or top
+export async function getUserPosts(username: string, sort = "new", page = 1, limit = 20) {
+    const params = new URLSearchParams({ sort, page: String(page), limit: String(limit) });
+
+    const response = await fetch(`${baseUrl}/user/${username}/posts?${params}`, {
+        method: "GET",
+        headers: { "Content-Type": "application/json" },
+        credentials: "include",
+    });
+
+    if (!response.ok) throw new Error("Failed to load posts");
+
+    const data = await response.json();
+    // total is left out when the user hides their stats
+    return { posts: data.posts as Post[], total: data.total as number | undefined };
+}
+
+// Resolves to null when the user hides their comment history
+export async function getUserComments(username: string, sort = "new", page = 1, limit = 20) {
+    const params = new URLSearchParams({ sort, page: String(page), limit: String(limit) });
+
+    const response = await fetch(`${baseUrl}/user/${username}/comments?${params}`, {
+        method: "GET",
+        headers: { "Content-Type": "application/json" },
+        credentials: "include",
+    });
+
+    if (response.status === 403) return null;
+    if (!response.ok) throw new Error("Failed to load comments");
+
+    const data = await response.json();
+    return { comments: data.comments as Comment[], total: data.total as number | undefined };
+}
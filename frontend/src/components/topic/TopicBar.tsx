@@ -1,5 +1,6 @@
 import { Box, Chip } from "@mui/material";
 import { Link } from "react-router-dom";
+import { useAppSelector } from "../../hooks/reduxHooks";
 
 // Props for topic bar. 
 interface Props {
@@ -10,6 +11,8 @@ interface Props {
 
 // Topic Bar component 
 const TopicBar = ({ topics, selectedTopic, onSelect }: Props) => {
+    const user = useAppSelector(state => state.auth.user);
+
     return (
         <Box display="flex" gap={1} flexWrap="wrap">
             {topics.map((topic) => (
@@ -22,9 +25,9 @@ const TopicBar = ({ topics, selectedTopic, onSelect }: Props) => {
                     onClick={() => onSelect(topic)}
                 />
             ))}
-            {/* Allows user to create a topic */}
+            {/* Admins create topics, everyone else suggests them for an admin to approve */}
             <Chip
-                label="+"
+                label={user?.isAdmin ? "+" : "Suggest a topic"}
                 clickable
                 sx={{
                     '&:hover': {
@@ -110,12 +110,12 @@ const CreatePostPage = () => {
                             {topic.name}
                         </MenuItem>
                     ))}
-                    {/* Allows users to create a new topic if not there */}
+                    {/* Allows users to create a new topic if not there, or suggest one if they are not an admin */}
                     <MenuItem
                         onClick={() => navigate('/topics/create')}
                         sx={{ fontStyle: 'italic', backgroundColor: '#f0f0f5' }}
                     >
-                        + Create New Topic
+                        {user?.isAdmin ? "+ Create New Topic" : "+ Suggest a Topic"}
                     </MenuItem>
                 </Select>
             </FormControl>
@@ -1,8 +1,8 @@
 import { Container, Typography, Box, Avatar, Card, CardContent, CircularProgress, Alert, Chip, Stack, Divider } from '@mui/material';
 import { useParams } from 'react-router-dom';
 import { useEffect, useState } from 'react';
-import { getUserProfile } from '../../api/handleUser';
-import type { UserProfile } from '../../types/globalTypes';
+import { getUserComments, getUserPosts, getUserProfile } from '../../api/handleUser';
+import type { Comment, Post, UserProfile } from '../../types/globalTypes';
 import PostCard from '../../components/post/PostCard';
 import CommentList from '../../components/comments/CommentList';
 
@@ -10,6 +10,8 @@ const ProfilePage = () => {
     const { username } = useParams<{ username: string }>();
 
     const [user, setUser] = useState<UserProfile | null>(null);
+    const [posts, setPosts] = useState<Post[]>([]);
+    const [comments, setComments] = useState<Comment[] | null>([]);
     const [loading, setLoading] = useState(true);
     const [error, setError] = useState("");
 
@@ -25,9 +27,15 @@ const ProfilePage = () => {
                 setLoading(true);
                 setError("");
 
-                const data = await getUserProfile(username, true, true);
+                // First page of each for now
+                const [data, postPage, commentPage] = await Promise.all([
+                    getUserProfile(username),
+                    getUserPosts(username),
+                    getUserComments(username),
+                ]);
                 setUser(data);
-                console.log(data.avatarUrl);
+                setPosts(postPage.posts);
+                setComments(commentPage ? commentPage.comments : null);
             } catch (err: unknown) {
                 if (err instanceof Error) {
                     setError(err.message); 
@@ -88,6 +96,12 @@ const ProfilePage = () => {
                                     <Chip label="Admin" color="primary" size="small" />
                                 )}
                             </Box>
+                            {user.displayName && (
+                                <Typography color="text.secondary">{user.displayName}</Typography>
+                            )}
+                            {user.bio && (
+                                <Typography variant="body2" sx={{ mt: 1 }}>{user.bio}</Typography>
+                            )}
                         </Box>
                     </Box>
 
@@ -95,22 +109,36 @@ const ProfilePage = () => {
 
                     {/* Stats Section */}
                     <Stack direction="row" spacing={4} sx={{ mt: 2 }}>
-                        <Box>
-                            <Typography variant="h6" color="primary">
-                                {user.postCount || 0}
-                            </Typography>
-                            <Typography variant="body2" color="text.secondary">
-                                Posts
-                            </Typography>
-                        </Box>
-                        <Box>
-                            <Typography variant="h6" color="primary">
-                                {user.commentCount || 0}
-                            </Typography>
-                            <Typography variant="body2" color="text.secondary">
-                                Comments
-                            </Typography>
-                        </Box>
+                        {!user.statsHidden && (
+                            <Box>
+                                <Typography variant="h6" color="primary">
+                                    {user.postCount || 0}
+                                </Typography>
+                                <Typography variant="body2" color="text.secondary">
+                                    Posts
+                                </Typography>
+                            </Box>
+                        )}
+                        {!user.statsHidden && !user.commentsHidden && (
+                            <Box>
+                                <Typography variant="h6" color="primary">
+                                    {user.commentCount || 0}
+                                </Typography>
+                                <Typography variant="body2" color="text.secondary">
+                                    Comments
+                                </Typography>
+                            </Box>
+                        )}
+                        {user.karma && (
+                            <Box>
+                                <Typography variant="h6" color="primary">
+                                    {user.karma.total}
+                                </Typography>
+                                <Typography variant="body2" color="text.secondary">
+                                    Karma
+                                </Typography>
+                            </Box>
+                        )}
                         <Box>
                             <Typography variant="body2" color="text.secondary">
                                 Joined {new Date(user.createdAt).toLocaleDateString()}
@@ -123,9 +151,8 @@ const ProfilePage = () => {
                 <Typography variant="h5" gutterBottom>
                     Posts by {user.username}
                 </Typography>
-                {user.posts && user.posts.length > 0 ? (
-                    console.log(user.posts),
-                    user.posts.map((post) => (
+                {posts.length > 0 ? (
+                    posts.map((post) => (
                         <PostCard
                             key={post.id}
                             post={post}
@@ -140,10 +167,11 @@ const ProfilePage = () => {
                     <Typography variant="h5" gutterBottom>
                         Comments by {user.username}
                     </Typography>
-                    {user.comments && user.comments.length > 0 ? (
-                        console.log(user.comments),
+                    {comments === null ? (
+                        <Typography>{user.username} keeps their comments private.</Typography>
+                    ) : comments.length > 0 ? (
                         <CommentList
-                            comments={user.comments}
+                            comments={comments}
                         />
                     ) : (
                         <Typography>No Comments to display.</Typography>
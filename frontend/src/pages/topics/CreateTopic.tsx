@@ -1,15 +1,16 @@
 import { useState, useEffect } from "react";
 import { Box, Button, TextField, Typography } from "@mui/material";
-import { createTopic } from "../../api/handleTopic";
+import { createTopic, proposeTopic } from "../../api/handleTopic";
 import { formatTopicName } from "../../helpers/formatter";
 import { useNavigate } from "react-router-dom";
 import { useAppSelector } from "../../hooks/reduxHooks";
 import { toast } from "react-hot-toast";
 
-// Page to create a new topic
+// Page to create a new topic - admins create it straight away, everyone else proposes it for review
 const AddTopic = () => {
     const user = useAppSelector(state => state.auth.user);
     const [name, setName] = useState("");
+    const [reason, setReason] = useState("");
     const navigate = useNavigate();
 
     // If the user is not found in state, redirects them to signup
@@ -19,7 +20,7 @@ const AddTopic = () => {
 
     if (!user) return <p>Loading...</p>;
 
-    // function handles the submission of a new topic 
+    // function handles the submission of a new topic
     const handleSubmit = async () => {
         // ensures name is not empty
         if (!name.trim()) {
@@ -27,10 +28,15 @@ const AddTopic = () => {
             return;
         }
 
-        // Creates a topic via backend 
+        // Creates or proposes the topic via backend
         try {
-            await createTopic(formatTopicName(name));
+            if (user.isAdmin) {
+                await createTopic(formatTopicName(name));
+            } else {
+                await proposeTopic(formatTopicName(name), reason.trim());
+            }
             setName("");
+            setReason("");
             navigate(-1);
         } catch (err: unknown) {
             if (err instanceof Error) {
@@ -43,15 +49,26 @@ const AddTopic = () => {
 
     return (
         <Box maxWidth="400px" mx="auto" mt={6} display="flex" flexDirection="column" gap={2}>
-            <Typography variant="h5">Add New Topic</Typography>
+            <Typography variant="h5">{user.isAdmin ? "Add New Topic" : "Suggest a Topic"}</Typography>
             <TextField
                 label="Topic Name"
                 value={name}
                 onChange={(e) => setName(e.target.value)}
                 fullWidth
             />
+            {/* Helps the admins decide on the proposal */}
+            {!user.isAdmin && (
+                <TextField
+                    label="Why should this topic exist?"
+                    value={reason}
+                    onChange={(e) => setReason(e.target.value)}
+                    multiline
+                    minRows={3}
+                    fullWidth
+                />
+            )}
             <Button variant="contained" onClick={handleSubmit}>
-                Create Topic
+                {user.isAdmin ? "Create Topic" : "Suggest Topic"}
             </Button>
         </Box>
     );
@@ -47,9 +47,13 @@ export interface UserProfile {
     username: string;
     avatarUrl: string;
     isAdmin: boolean;
-    postCount: number;
-    commentCount: number;
+    displayName: string;
+    bio: string;
+    // left out when the user hides them
+    postCount?: number;
+    commentCount?: number;
+    karma?: { post: number; comment: number; total: number };
+    commentsHidden: boolean;
+    statsHidden: boolean;
     createdAt: string;
-    posts?: Post[];
-    comments?: Comment[];
 }
\ No newline at end of file